@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+)
+
+// copyScopes are the parts of the live index carried over to the temporary index
+var copyScopes = []string{"settings", "synonyms", "rules"}
+
+// AtomicUploadIndex builds a temporary index from the given objects, copying
+// the settings, synonyms and rules of the live index and then applying any
+// extra settings, which extend the custom ranking copied from the live index
+// instead of replacing it, and moves it over the live index. The live index
+// is left untouched if any step fails.
+func AtomicUploadIndex(client algoliasearch.Client, name string, objects []algoliasearch.Object, settings algoliasearch.Map) error {
+	settings, err := keepCustomRanking(client, name, settings)
+	if err != nil {
+		return err
+	}
+
+	return ReplaceIndex(client, name, true, func(tmp algoliasearch.Index) error {
+		log.Info("Uploading objects")
+		if err := AddObjects(tmp, objects, true); err != nil {
+			return err
+		}
+		if len(settings) == 0 {
+			return nil
+		}
+		log.Info("Applying settings")
+		return PushSettings(tmp, settings)
+	})
+}
+
+// ReplaceIndex fills a temporary index with populate and then moves it over the
+// named index. When copyLive is set, the settings, synonyms and rules of the
+// named index are copied to the temporary index first. The named index is left
+// untouched and the temporary index deleted if any step fails.
+func ReplaceIndex(client algoliasearch.Client, name string, copyLive bool, populate func(tmp algoliasearch.Index) error) (err error) {
+	tmpName := fmt.Sprintf("%s_tmp_%d", name, time.Now().Unix())
+	live := client.InitIndex(name)
+	tmp := client.InitIndex(tmpName)
+
+	exists := false
+	if copyLive {
+		if exists, err = IndexExists(client, name); err != nil {
+			return err
+		}
+	}
+
+	// From here on the temporary index may exist, so get rid of it on failure.
+	// Once the move has been accepted the temporary index belongs to Algolia.
+	moved := false
+	defer func() {
+		if err == nil || moved {
+			return
+		}
+		log.WithField("index", tmpName).Info("Deleting temporary index")
+		if _, derr := client.DeleteIndex(tmpName); derr != nil {
+			log.WithError(derr).WithField("index", tmpName).Warn("Failed to delete temporary index")
+		}
+	}()
+
+	var res algoliasearch.UpdateTaskRes
+	if exists {
+		log.WithField("index", tmpName).Info("Copying settings, synonyms and rules")
+		if res, err = client.ScopedCopyIndex(name, tmpName, copyScopes); err != nil {
+			return err
+		}
+		if err = live.WaitTask(res.TaskID); err != nil {
+			return err
+		}
+	}
+
+	log.WithField("index", tmpName).Info("Populating temporary index")
+	if err = populate(tmp); err != nil {
+		return err
+	}
+
+	log.WithField("index", name).Info("Moving temporary index into place")
+	if res, err = client.MoveIndex(tmpName, name); err != nil {
+		return err
+	}
+	moved = true
+	return tmp.WaitTask(res.TaskID)
+}
+
+// IndexExists reports whether the application has an index with the given name
+func IndexExists(client algoliasearch.Client, name string) (bool, error) {
+	indexes, err := client.ListIndexes()
+	if err != nil {
+		return false, err
+	}
+
+	for _, index := range indexes {
+		if index.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AddObjects uploads the objects in batches, optionally waiting for Algolia to
+// finish indexing each batch
+func AddObjects(index algoliasearch.Index, objects []algoliasearch.Object, wait bool) error {
+	for start := 0; start < len(objects); start += batchSize {
+		end := start + batchSize
+		if end > len(objects) {
+			end = len(objects)
+		}
+
+		res, err := index.AddObjects(objects[start:end])
+		if err != nil {
+			return err
+		}
+		if wait {
+			if err = index.WaitTask(res.TaskID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// tmpNames replaces the generated names of temporary indexes in calls with "tmp"
+func tmpNames(calls []string) []string {
+	var result []string
+	for _, c := range calls {
+		fields := strings.Fields(c)
+		for i, f := range fields {
+			if strings.HasPrefix(f, "docs_tmp_") {
+				fields[i] = "tmp"
+			}
+		}
+		result = append(result, strings.Join(fields, " "))
+	}
+	return result
+}
+
+func TestReplaceIndex(t *testing.T) {
+	populateErr := errors.New("populate failed")
+	tests := []struct {
+		name     string
+		exists   bool
+		copyLive bool
+		populate error
+		fail     []string
+		calls    []string
+		err      string
+	}{
+		{
+			"copies the live index",
+			true, true, nil, nil,
+			[]string{"listIndexes", "copy docs to tmp", "addObjects tmp", "move tmp to docs"},
+			"",
+		},
+		{
+			"new index",
+			false, true, nil, nil,
+			[]string{"listIndexes", "addObjects tmp", "move tmp to docs"},
+			"",
+		},
+		{
+			"without copying",
+			true, false, nil, nil,
+			[]string{"addObjects tmp", "move tmp to docs"},
+			"",
+		},
+		{
+			"populate fails",
+			true, true, populateErr, nil,
+			[]string{"listIndexes", "copy docs to tmp", "addObjects tmp", "delete tmp"},
+			"populate failed",
+		},
+		{
+			"copy fails",
+			true, true, nil, []string{"copy"},
+			[]string{"listIndexes", "copy docs to tmp", "delete tmp"},
+			"failed",
+		},
+		{
+			"move fails",
+			true, true, nil, []string{"move"},
+			[]string{"listIndexes", "copy docs to tmp", "addObjects tmp", "move tmp to docs", "delete tmp"},
+			"failed",
+		},
+		{
+			"listing fails",
+			true, true, nil, []string{"listIndexes"},
+			[]string{"listIndexes"},
+			"failed",
+		},
+	}
+
+	for _, tt := range tests {
+		live := &fakeIndex{
+			name:     "docs",
+			settings: algoliasearch.Map{"customRanking": []string{"desc(date)"}},
+			objects:  []algoliasearch.Object{{"objectID": "old"}},
+		}
+		client := newFakeClient()
+		if tt.exists {
+			client = newFakeClient(live)
+		}
+		client.fail = tt.fail
+
+		err := ReplaceIndex(client, "docs", tt.copyLive, func(tmp algoliasearch.Index) error {
+			if _, aerr := tmp.AddObjects([]algoliasearch.Object{{"objectID": "new"}}); aerr != nil {
+				return aerr
+			}
+			return tt.populate
+		})
+		if got := tmpNames(client.calls); !reflect.DeepEqual(got, tt.calls) {
+			t.Errorf("%s: calls = %q, want %q", tt.name, got, tt.calls)
+		}
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+			}
+			if len(client.indexes) > 1 {
+				t.Errorf("%s: the temporary index was left behind: %v", tt.name, client.indexes)
+			}
+			if tt.exists && (client.indexes["docs"] != live || len(live.objects) != 1 || live.objects[0]["objectID"] != "old") {
+				t.Errorf("%s: the live index was changed", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+
+		replaced := client.indexes["docs"]
+		if len(client.indexes) != 1 || replaced == nil || len(replaced.objects) != 1 || replaced.objects[0]["objectID"] != "new" {
+			t.Errorf("%s: indexes after the move = %v", tt.name, client.indexes)
+			continue
+		}
+		wantSettings := algoliasearch.Map(nil)
+		if tt.exists && tt.copyLive {
+			wantSettings = live.settings
+		}
+		if !reflect.DeepEqual(replaced.settings, wantSettings) {
+			t.Errorf("%s: settings = %v, want %v", tt.name, replaced.settings, wantSettings)
+		}
+	}
+}
+
+func TestAtomicUploadIndexKeepsCustomRanking(t *testing.T) {
+	live := &fakeIndex{name: "docs", settings: algoliasearch.Map{"customRanking": []string{"desc(date)"}}}
+	client := newFakeClient(live)
+
+	objects := []algoliasearch.Object{{"objectID": "a"}}
+	settings := algoliasearch.Map{"customRanking": []string{"desc(priority)"}, "distinct": true}
+	if err := AtomicUploadIndex(client, "docs", objects, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	want := algoliasearch.Map{"customRanking": []string{"desc(date)", "desc(priority)"}, "distinct": true}
+	if got := client.indexes["docs"]; got == nil || !reflect.DeepEqual(got.settings, want) || len(got.objects) != 1 {
+		t.Errorf("index after upload = %+v, want settings %v", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+)
+
+// backupVersion is the version of the backup archive format
+const backupVersion = 1
+
+// BackupHeader is the first line of a backup archive. Every following line
+// holds one record of the index.
+type BackupHeader struct {
+	Version   int                     `json:"version"`
+	Index     string                  `json:"index"`
+	CreatedAt time.Time               `json:"createdAt"`
+	Settings  map[string]interface{}  `json:"settings"`
+	Synonyms  []algoliasearch.Synonym `json:"synonyms"`
+	Rules     []algoliasearch.Rule    `json:"rules"`
+}
+
+// BackupIndex writes the settings, synonyms, rules and every record of the
+// index to w as NDJSON, streaming the records as they are browsed. The
+// settings are stored as the API returned them. It returns the number of
+// records written.
+func BackupIndex(index algoliasearch.Index, name string, settings algoliasearch.Map, w io.Writer) (int, error) {
+	header := BackupHeader{Version: backupVersion, Index: name, CreatedAt: time.Now().UTC(), Settings: settings}
+
+	var err error
+	log.Info("Fetching synonyms")
+	if header.Synonyms, err = GetSynonyms(index); err != nil {
+		return 0, err
+	}
+
+	log.Info("Fetching rules")
+	if header.Rules, err = GetRules(index); err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(header); err != nil {
+		return 0, err
+	}
+
+	log.Info("Browsing objects")
+	count := 0
+	it, err := index.BrowseAll(algoliasearch.Map{})
+	for err == nil {
+		var hit algoliasearch.Map
+		if hit, err = it.Next(); err == nil {
+			if err = enc.Encode(hit); err == nil {
+				count++
+			}
+		}
+	}
+	if err != algoliasearch.NoMoreHitsErr {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// RestoreIndex recreates the named index from a backup archive read from r.
+// The index is rebuilt in a temporary index and moved into place, so the
+// existing index is left untouched if the restore fails. The replicas of the
+// existing index stay linked to it; those recorded in the backup are only
+// reported. It returns the number of records restored.
+func RestoreIndex(client algoliasearch.Client, name string, r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var header BackupHeader
+	if err := dec.Decode(&header); err != nil {
+		return 0, fmt.Errorf("reading backup header: %s", err)
+	}
+	if header.Version != backupVersion {
+		return 0, fmt.Errorf("unsupported backup version %d", header.Version)
+	}
+	for i, rule := range header.Rules {
+		params, err := ruleParams(rule.Consequence.Params)
+		if err != nil {
+			return 0, fmt.Errorf("backup rule %s: %s", rule.ObjectID, err)
+		}
+		header.Rules[i].Consequence.Params = params
+	}
+
+	// Replicas must not follow the temporary index. The replicas of the live
+	// index are linked to the restored index once it has been moved into place.
+	replicas, err := indexReplicas(client, name)
+	if err != nil {
+		return 0, err
+	}
+	settings := SettingsMap(header.Settings)
+	for _, key := range []string{"replicas", "slaves", "primary"} {
+		if v, ok := settings[key]; ok && len(replicas) == 0 {
+			log.WithField(key, v).Warn("The backup's " + key + " setting is not restored")
+		}
+		delete(settings, key)
+	}
+
+	count := 0
+	err = ReplaceIndex(client, name, false, func(tmp algoliasearch.Index) error {
+
+		log.Info("Restoring settings")
+		if err := PushSettings(tmp, settings); err != nil {
+			return err
+		}
+
+		if len(header.Synonyms) > 0 {
+			log.WithField("count", len(header.Synonyms)).Info("Restoring synonyms")
+			if err := ReplaceSynonyms(tmp, header.Synonyms, false); err != nil {
+				return err
+			}
+		}
+
+		if len(header.Rules) > 0 {
+			log.WithField("count", len(header.Rules)).Info("Restoring rules")
+			if err := ReplaceRules(tmp, header.Rules, false); err != nil {
+				return err
+			}
+		}
+
+		log.Info("Restoring objects")
+		batch := make([]algoliasearch.Object, 0, batchSize)
+		for {
+			var object algoliasearch.Object
+			if err := dec.Decode(&object); err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("reading record %d: %s", count+1, err)
+			}
+
+			batch = append(batch, object)
+			if len(batch) == batchSize {
+				if err := AddObjects(tmp, batch, true); err != nil {
+					return err
+				}
+				count += len(batch)
+				batch = batch[:0]
+			}
+		}
+		if err := AddObjects(tmp, batch, true); err != nil {
+			return err
+		}
+		count += len(batch)
+		return nil
+	})
+	if err != nil || len(replicas) == 0 {
+		return count, err
+	}
+
+	log.WithField("replicas", strings.Join(replicas, ", ")).Info("Linking replicas")
+	if err = PushSettings(client.InitIndex(name), algoliasearch.Map{"replicas": replicas}); err != nil {
+		return count, fmt.Errorf("linking replicas: %s", err)
+	}
+	return count, nil
+}
+
+// indexReplicas returns the replicas of the named index, if it exists
+func indexReplicas(client algoliasearch.Client, name string) ([]string, error) {
+	exists, err := IndexExists(client, name)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	settings, err := client.InitIndex(name).GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	if len(settings.Replicas) > 0 {
+		return settings.Replicas, nil
+	}
+	return settings.Slaves, nil
+}
+
+// isGzip reports whether an archive file should be gzip compressed
+func isGzip(file string) bool {
+	return strings.HasSuffix(strings.ToLower(file), ".gz")
+}
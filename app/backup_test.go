@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestBackupIndexKeepsRawSettings(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/synonyms/search"):
+			_, _ = w.Write([]byte(`{"hits":[],"nbHits":0}`))
+		case strings.HasSuffix(r.URL.Path, "/rules/search"):
+			_, _ = w.Write([]byte(`{"hits":[],"nbHits":0,"page":0,"nbPages":0}`))
+		case strings.HasSuffix(r.URL.Path, "/browse"):
+			_, _ = w.Write([]byte(`{"hits":[{"objectID":"a"},{"objectID":"b"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := algoliasearch.NewClientWithHosts("app", "key", []string{strings.TrimPrefix(srv.URL, "https://")})
+	client.SetHTTPClient(srv.Client())
+
+	settings := algoliasearch.Map{
+		"queryLanguages":         []string{"en"},
+		"decompoundedAttributes": map[string]interface{}{"de": []interface{}{"title"}},
+		"hitsPerPage":            20,
+	}
+	var buf bytes.Buffer
+	count, err := BackupIndex(client.InitIndex("idx"), "idx", settings, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+
+	dec := json.NewDecoder(&buf)
+	dec.UseNumber()
+	var header BackupHeader
+	if err = dec.Decode(&header); err != nil {
+		t.Fatal(err)
+	}
+	if got := SettingsMap(header.Settings); !reflect.DeepEqual(got, settings) {
+		t.Errorf("settings = %#v, want %#v", got, settings)
+	}
+}
+
+func TestRestoreIndexKeepsReplicas(t *testing.T) {
+	backup := `{"version":1,"settings":{"hitsPerPage":10,"replicas":["old_replica"]}}
+{"objectID":"a"}
+{"objectID":"b"}
+`
+	tests := []struct {
+		name   string
+		live   *fakeIndex
+		want   algoliasearch.Map
+		pushes int
+	}{
+		{
+			"live index with replicas",
+			&fakeIndex{name: "docs", settings: algoliasearch.Map{"replicas": []string{"docs_by_date"}}},
+			algoliasearch.Map{"hitsPerPage": 10, "replicas": []string{"docs_by_date"}},
+			2,
+		},
+		{
+			"new index",
+			nil,
+			algoliasearch.Map{"hitsPerPage": 10},
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		client := newFakeClient()
+		if tt.live != nil {
+			client = newFakeClient(tt.live)
+		}
+
+		count, err := RestoreIndex(client, "docs", strings.NewReader(backup))
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if count != 2 {
+			t.Errorf("%s: count = %d, want 2", tt.name, count)
+		}
+
+		restored := client.indexes["docs"]
+		if restored == nil || len(restored.objects) != 2 || !reflect.DeepEqual(restored.settings, tt.want) {
+			t.Errorf("%s: restored index = %+v, want settings %v", tt.name, restored, tt.want)
+		}
+		setSettings := 0
+		for _, c := range client.calls {
+			if strings.HasPrefix(c, "setSettings") {
+				setSettings++
+			}
+		}
+		if setSettings != tt.pushes {
+			t.Errorf("%s: calls = %q, want %d setSettings calls", tt.name, client.calls, tt.pushes)
+		}
+	}
+}
+
+func TestRestoreIndexRejectsUnsupportedRules(t *testing.T) {
+	backup := `{"version":1,"settings":{},"rules":[{"objectID":"r","consequence":{"params":{"query":{"edits":[]}}}}]}
+{"objectID":"a"}
+`
+	client := newFakeClient(&fakeIndex{name: "docs"})
+	_, err := RestoreIndex(client, "docs", strings.NewReader(backup))
+	if err == nil || !strings.Contains(err.Error(), `backup rule r: query edit "edits" is not supported`) {
+		t.Errorf("RestoreIndex: error = %v", err)
+	}
+	if len(client.calls) != 0 {
+		t.Errorf("calls = %v, want the backup to be rejected before any", client.calls)
+	}
+}
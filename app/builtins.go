@@ -0,0 +1,320 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// builtin is a function that expressions can call
+type builtin struct {
+	minArgs int
+	// maxArgs is -1 for functions taking any number of arguments
+	maxArgs int
+	// acceptsNull is set for functions that handle a null first argument
+	// themselves, instead of returning null
+	acceptsNull bool
+	call        func(args []interface{}) (interface{}, error)
+}
+
+// builtins is the standard library of the expression language
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		// Strings
+		"lower":      stringFunc(strings.ToLower),
+		"upper":      stringFunc(strings.ToUpper),
+		"title":      stringFunc(strings.Title),
+		"trim":       stringFunc(strings.TrimSpace),
+		"plain":      stringFunc(HTMLToText),
+		"urlize":     stringFunc(Urlize),
+		"string":     {1, 1, true, func(args []interface{}) (interface{}, error) { return toString(args[0]), nil }},
+		"replace":    {3, 3, false, builtinReplace},
+		"split":      {2, 2, false, builtinSplit},
+		"join":       {2, 2, false, builtinJoin},
+		"startsWith": {2, 2, false, builtinStartsWith},
+		"endsWith":   {2, 2, false, builtinEndsWith},
+		"contains":   {2, 2, true, func(args []interface{}) (interface{}, error) { return contains(args[0], args[1]), nil }},
+		"substr":     {2, 3, false, builtinSubstr},
+		"truncate":   {2, 2, false, builtinTruncate},
+		"words":      {1, 1, true, builtinWords},
+		"len":        {1, 1, true, builtinLen},
+
+		// Math
+		"number": {1, 1, false, func(args []interface{}) (interface{}, error) { return toNumber(args[0]) }},
+		"round":  {1, 2, false, builtinRound},
+		"floor":  mathFunc(math.Floor),
+		"ceil":   mathFunc(math.Ceil),
+		"abs":    mathFunc(math.Abs),
+		"min":    {1, -1, false, func(args []interface{}) (interface{}, error) { return extreme(args, -1) }},
+		"max":    {1, -1, false, func(args []interface{}) (interface{}, error) { return extreme(args, 1) }},
+
+		// Dates
+		"date":   {1, 1, false, func(args []interface{}) (interface{}, error) { return toTime(args[0]) }},
+		"year":   dateFunc(func(t time.Time) float64 { return float64(t.Year()) }),
+		"month":  dateFunc(func(t time.Time) float64 { return float64(t.Month()) }),
+		"day":    dateFunc(func(t time.Time) float64 { return float64(t.Day()) }),
+		"unix":   dateFunc(func(t time.Time) float64 { return float64(t.Unix()) }),
+		"format": {2, 2, false, builtinFormat},
+
+		// Lists
+		"first":    {1, 1, false, builtinFirst},
+		"last":     {1, 1, false, builtinLast},
+		"unique":   {1, 1, false, builtinUnique},
+		"sort":     {1, 1, false, builtinSort},
+		"coalesce": {1, -1, true, builtinCoalesce},
+	}
+}
+
+func stringFunc(f func(string) string) builtin {
+	return builtin{1, 1, false, func(args []interface{}) (interface{}, error) {
+		return f(toString(args[0])), nil
+	}}
+}
+
+func mathFunc(f func(float64) float64) builtin {
+	return builtin{1, 1, false, func(args []interface{}) (interface{}, error) {
+		n, err := toNumber(args[0])
+		return f(n), err
+	}}
+}
+
+func dateFunc(f func(time.Time) float64) builtin {
+	return builtin{1, 1, false, func(args []interface{}) (interface{}, error) {
+		t, err := toTime(args[0])
+		return f(t), err
+	}}
+}
+
+func builtinReplace(args []interface{}) (interface{}, error) {
+	return strings.Replace(toString(args[0]), toString(args[1]), toString(args[2]), -1), nil
+}
+
+func builtinSplit(args []interface{}) (interface{}, error) {
+	var list []interface{}
+	for _, s := range strings.Split(toString(args[0]), toString(args[1])) {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func builtinJoin(args []interface{}) (interface{}, error) {
+	list, err := toList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = toString(item)
+	}
+	return strings.Join(items, toString(args[1])), nil
+}
+
+func builtinStartsWith(args []interface{}) (interface{}, error) {
+	return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+}
+
+func builtinEndsWith(args []interface{}) (interface{}, error) {
+	return strings.HasSuffix(toString(args[0]), toString(args[1])), nil
+}
+
+// builtinSubstr returns length characters from start, or the rest of the string
+func builtinSubstr(args []interface{}) (interface{}, error) {
+	runes := []rune(toString(args[0]))
+	start, err := toInt(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("start: %s", err)
+	}
+	from := clamp(start, len(runes))
+	to := len(runes)
+	if len(args) == 3 {
+		var length int
+		if length, err = toInt(args[2]); err != nil {
+			return nil, fmt.Errorf("length: %s", err)
+		}
+		to = clamp(from+clamp(length, len(runes)), len(runes))
+	}
+	if to < from {
+		return "", nil
+	}
+	return string(runes[from:to]), nil
+}
+
+func clamp(i, max int) int {
+	switch {
+	case i < 0:
+		return 0
+	case i > max:
+		return max
+	}
+	return i
+}
+
+func builtinTruncate(args []interface{}) (interface{}, error) {
+	n, err := toInt(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("length: %s", err)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("negative length")
+	}
+	return truncate(toString(args[0]), n), nil
+}
+
+// builtinWords counts the words in a string
+func builtinWords(args []interface{}) (interface{}, error) {
+	return float64(len(strings.Fields(toString(args[0])))), nil
+}
+
+// builtinLen counts the characters of a string, the items of a list or the
+// attributes of an object, with 0 for null
+func builtinLen(args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return 0.0, nil
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+	return nil, fmt.Errorf("cannot take the length of %s", describeValue(args[0]))
+}
+
+// maxRoundPlaces is the most decimal places round can keep, as float64 has
+// no more significant digits
+const maxRoundPlaces = 17
+
+// builtinRound rounds to the nearest whole number, or to a number of decimal places
+func builtinRound(args []interface{}) (interface{}, error) {
+	n, err := toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	places := 0
+	if len(args) == 2 {
+		if places, err = toInt(args[1]); err != nil {
+			return nil, fmt.Errorf("places: %s", err)
+		}
+		if places < -maxRoundPlaces || places > maxRoundPlaces {
+			return nil, fmt.Errorf("places: %d is out of range", places)
+		}
+	}
+	scale := math.Pow(10, float64(places))
+	rounded := math.Floor(n*scale+0.5) / scale
+	if math.IsNaN(rounded) || math.IsInf(rounded, 0) {
+		// The number is too large to have any digits at that place
+		return n, nil
+	}
+	return rounded, nil
+}
+
+// extreme returns the smallest (sign -1) or largest (sign 1) of its
+// arguments, or of the items of a single list argument. null is skipped.
+func extreme(args []interface{}, sign int) (interface{}, error) {
+	if len(args) == 1 {
+		list, err := toList(args[0])
+		if err != nil {
+			return nil, err
+		}
+		args = list
+	}
+
+	var best interface{}
+	for _, v := range args {
+		if v == nil {
+			continue
+		}
+		if best == nil {
+			best = v
+			continue
+		}
+		op := "<"
+		if sign > 0 {
+			op = ">"
+		}
+		better, err := compare(op, v, best)
+		if err != nil {
+			return nil, err
+		}
+		if better == true {
+			best = v
+		}
+	}
+	return best, nil
+}
+
+// builtinFormat formats a date with a Go layout such as "2006-01-02"
+func builtinFormat(args []interface{}) (interface{}, error) {
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return t.Format(toString(args[1])), nil
+}
+
+func builtinFirst(args []interface{}) (interface{}, error) {
+	list, err := toList(args[0])
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+func builtinLast(args []interface{}) (interface{}, error) {
+	list, err := toList(args[0])
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return list[len(list)-1], nil
+}
+
+func builtinUnique(args []interface{}) (interface{}, error) {
+	list, err := toList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, item := range list {
+		if !contains(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// builtinSort sorts a list of numbers, strings or dates
+func builtinSort(args []interface{}) (interface{}, error) {
+	list, err := toList(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]interface{}{}, list...)
+	var sortErr error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		less, cerr := compare("<", sorted[i], sorted[j])
+		if cerr != nil {
+			sortErr = cerr
+		}
+		return less == true
+	})
+	return sorted, sortErr
+}
+
+// builtinCoalesce returns the first argument that is not null or empty
+func builtinCoalesce(args []interface{}) (interface{}, error) {
+	for _, v := range args {
+		if !isBlank(v) {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// ComputedAttribute sets an attribute of every record to the value of an expression
+type ComputedAttribute struct {
+	// Attribute is the attribute to set. Dots reach into nested objects, as in hierarchy.lvl0.
+	Attribute string `mapstructure:"attribute"`
+	// Expression is evaluated against each record
+	Expression string `mapstructure:"expression"`
+}
+
+// ComputedAttributes is an ordered list of computed attributes. Each one can
+// use the attributes computed before it.
+type ComputedAttributes []ComputedAttribute
+
+// Compile parses every expression, reporting the first one that is invalid
+func (cs ComputedAttributes) Compile() ([]*Expression, error) {
+	exprs := make([]*Expression, len(cs))
+	for i, c := range cs {
+		if c.Attribute == "" {
+			return nil, fmt.Errorf("computed attribute %d: missing attribute", i)
+		}
+		expr, err := CompileExpression(c.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("computed attribute %q: %s", c.Attribute, err)
+		}
+		exprs[i] = expr
+	}
+	return exprs, nil
+}
+
+// Apply evaluates the expressions against every record in place. An
+// expression that evaluates to null leaves the attribute unset.
+func (cs ComputedAttributes) Apply(objects []algoliasearch.Object) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	exprs, err := cs.Compile()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i, o := range objects {
+		for j, expr := range exprs {
+			v, evalErr := expr.Eval(o, now)
+			if evalErr != nil {
+				return fmt.Errorf("computed attribute %q: record %d: %s", cs[j].Attribute, i, evalErr)
+			}
+			if v != nil {
+				setAttribute(o, cs[j].Attribute, v)
+			}
+		}
+	}
+	return nil
+}
+
+// setAttribute sets a possibly nested attribute, creating the objects on the way
+func setAttribute(object algoliasearch.Object, attribute string, v interface{}) {
+	path := strings.Split(attribute, ".")
+	m := map[string]interface{}(object)
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = v
+}
+
+// getAttribute returns a possibly nested attribute, or nil when it is missing
+func getAttribute(object algoliasearch.Object, attribute string) interface{} {
+	var v interface{} = map[string]interface{}(object)
+	for _, key := range strings.Split(attribute, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
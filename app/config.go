@@ -1,6 +1,12 @@
 package app
 
 import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/algolia/algoliasearch-client-go/algoliasearch"
 	"github.com/apex/log"
 )
@@ -11,11 +17,57 @@ type Config struct {
 	AlgoliaIndexName string `mapstructure:"algolia_index_name"`
 	UploadFile       string `mapstructure:"upload_file"`
 	Verbose          bool
+	DryRun           bool               `mapstructure:"dry_run"`
+	FromContent      bool               `mapstructure:"-"`
+	FromPublic       bool               `mapstructure:"-"`
+	SiteDir          string             `mapstructure:"-"`
+	Crawl            CrawlConfig        `mapstructure:"crawl"`
+	Sitemap          *SitemapConfig     `mapstructure:"sitemap"`
+	Languages        []Language         `mapstructure:"languages"`
+	IndexTemplate    string             `mapstructure:"index_template"`
+	Lang             string             `mapstructure:"-"`
+	Split            *SplitConfig       `mapstructure:"split"`
+	Validation       ValidationConfig   `mapstructure:"validation"`
+	Schema           Schema             `mapstructure:"schema"`
+	Transforms       Transforms         `mapstructure:"transforms"`
+	Normalize        []NormalizeConfig  `mapstructure:"normalize"`
+	Computed         ComputedAttributes `mapstructure:"computed"`
+	Plugins          Plugins            `mapstructure:"plugins"`
+	Lint             LintConfig         `mapstructure:"lint"`
+
+	dryRun    *DryRunRecorder
+	languages []*Config
+}
+
+// GetClient returns an Algolia API client for the configured application.
+// In dry run mode, mutating calls made through the client are only recorded.
+func (c *Config) GetClient() algoliasearch.Client {
+	client := newClient(c.AlgoliaAppID, c.AlgoliaAPIKey, newHTTPClient())
+	if !c.DryRun {
+		return client
+	}
+
+	if c.dryRun == nil {
+		c.dryRun = &DryRunRecorder{}
+	}
+	return NewDryRunClient(client, c.dryRun)
+}
+
+// DryRunOperations returns the operations recorded instead of being sent in
+// dry run mode, including those of the copies made by LanguageConfigs
+func (c *Config) DryRunOperations() []DryRunOperation {
+	var ops []DryRunOperation
+	if c.dryRun != nil {
+		ops = append(ops, c.dryRun.Operations...)
+	}
+	for _, lc := range c.languages {
+		ops = append(ops, lc.DryRunOperations()...)
+	}
+	return ops
 }
 
 func (c *Config) GetIndex() algoliasearch.Index {
-	client := algoliasearch.NewClient(c.AlgoliaAppID, c.AlgoliaAPIKey)
-	index := client.InitIndex(c.AlgoliaIndexName)
+	index := c.GetClient().InitIndex(c.AlgoliaIndexName)
 	return index
 }
 
@@ -24,6 +76,246 @@ func (c *Config) LoadUploadFile() ([]algoliasearch.Object, error) {
 	return LoadObjectFile(c.UploadFile)
 }
 
+// LoadObjects returns the records to upload, built from the Hugo content
+// directory in from-content mode, crawled from the rendered site in
+// from-public mode and read from the upload file otherwise, then filtered by
+// the sitemap, transformed, normalized, computed, passed through the plugins,
+// coerced to the schema and split
+func (c *Config) LoadObjects() ([]algoliasearch.Object, error) {
+	objects, _, err := c.loadObjects()
+	return objects, err
+}
+
+// loadObjects implements LoadObjects, also returning what each record was
+// built from so that validation can report the loaded records
+func (c *Config) loadObjects() ([]algoliasearch.Object, *recordSources, error) {
+	var objects []algoliasearch.Object
+	var err error
+	switch {
+	case len(c.Languages) > 0:
+		// Each language has its own records, loaded from its copy of the configuration
+		return nil, nil, fmt.Errorf("the records of a multilingual site are loaded one language at a time")
+	case c.FromContent && c.FromPublic:
+		return nil, nil, fmt.Errorf("--from-content and --from-public cannot be used together")
+	case c.FromContent:
+		// Splitting needs the headings of the pages
+		objects, err = LoadContentDir(c.SiteDir, c.Split != nil && c.Split.attribute() == "content")
+	case c.FromPublic:
+		objects, err = c.Crawl.CrawlSite(c.SiteDir)
+	default:
+		objects, err = c.LoadUploadFile()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	sources := newRecordSources(len(objects))
+
+	if c.Sitemap != nil {
+		kept, missing, serr := c.Sitemap.filter(c.SiteDir, objects)
+		if serr != nil {
+			return nil, nil, serr
+		}
+		objects = sources.keep(objects, kept)
+		for _, e := range missing {
+			log.WithField("url", e.Loc).Warn("Page in the sitemap has no record")
+		}
+	}
+
+	if err = c.Transforms.Check(); err != nil {
+		return nil, nil, err
+	}
+	transforms, normalize, split := c.splitStages()
+	if err = transforms.Apply(objects); err != nil {
+		return nil, nil, err
+	}
+
+	NormalizeObjects(normalize, objects)
+
+	if err = c.Computed.Apply(objects); err != nil {
+		return nil, nil, err
+	}
+
+	if len(c.Plugins) > 0 {
+		sources.remember(objects)
+		if objects, err = c.Plugins.Run(objects); err != nil {
+			return nil, nil, err
+		}
+		sources.retrace(objects)
+	}
+
+	if len(c.Schema) > 0 {
+		violations, serr := c.Schema.Coerce(objects)
+		if serr != nil {
+			return nil, nil, serr
+		}
+		if len(violations) > 0 {
+			sources.locate(violations)
+			return nil, nil, &ValidationError{Violations: violations}
+		}
+	}
+
+	if split != nil {
+		sources.remember(objects)
+		sources.parent = split.DistinctKey()
+		if objects, err = split.SplitObjects(objects); err != nil {
+			return nil, nil, err
+		}
+		sources.retrace(objects)
+	}
+
+	return objects, sources, nil
+}
+
+// splitStages returns the transforms, normalization and splitting to run on
+// the records. Converting the split attribute to plain text would remove the
+// headings it is split at, so its strip_html steps and normalization are left
+// to the splitter, which applies them to every section instead.
+func (c *Config) splitStages() (Transforms, []NormalizeConfig, *SplitConfig) {
+	if c.Split == nil {
+		return c.Transforms, c.Normalize, nil
+	}
+
+	split := *c.Split
+	attr := split.attribute()
+
+	var transforms Transforms
+	for _, t := range c.Transforms {
+		if t.Type == "strip_html" && t.Attribute == attr {
+			continue
+		}
+		transforms = append(transforms, t)
+	}
+
+	var normalize []NormalizeConfig
+	for _, n := range c.Normalize {
+		var attrs []string
+		for _, a := range n.Attributes {
+			if a == attr {
+				split.normalize = append(split.normalize, n.TextOptions)
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		if len(attrs) > 0 {
+			normalize = append(normalize, NormalizeConfig{Attributes: attrs, TextOptions: n.TextOptions})
+		}
+	}
+	return transforms, normalize, &split
+}
+
+// ValidateObjects loads the records to upload and checks them against the validation rules
+func (c *Config) ValidateObjects() ([]Violation, error) {
+	objects, sources, err := c.loadObjects()
+	if verr, ok := err.(*ValidationError); ok {
+		return verr.Violations, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return c.validate(objects, sources), nil
+}
+
+// validate checks the records built by loadObjects, reporting the loaded
+// records they came from
+func (c *Config) validate(objects []algoliasearch.Object, sources *recordSources) []Violation {
+	return c.Validation.validate(objects, sources.source)
+}
+
+// LintObjects loads the records to upload and runs the lint rules over them
+func (c *Config) LintObjects() ([]LintFinding, error) {
+	objects, err := c.LoadObjects()
+	if err != nil {
+		return nil, err
+	}
+	return c.Lint.Lint(objects, c.distinctKey())
+}
+
+// LintIndex runs the lint rules over the records of the configured index
+func (c *Config) LintIndex() ([]LintFinding, error) {
+	objects, err := BrowseObjects(c.GetIndex())
+	if err != nil {
+		return nil, err
+	}
+	return c.Lint.Lint(objects, c.distinctKey())
+}
+
+// distinctKey is the attribute shared by the records split from one page
+func (c *Config) distinctKey() string {
+	split := c.Split
+	if split == nil {
+		split = &SplitConfig{}
+	}
+	return split.DistinctKey()
+}
+
+// loadValidObjects loads the records to upload and refuses to return them if
+// any fails validation, logging every violation found
+func (c *Config) loadValidObjects() ([]algoliasearch.Object, error) {
+	objects, sources, err := c.loadObjects()
+	if err == nil {
+		log.WithField("records", len(objects)).Info("Validating objects")
+		if violations := c.validate(objects, sources); len(violations) > 0 {
+			err = &ValidationError{Violations: violations}
+		}
+	}
+
+	if verr, ok := err.(*ValidationError); ok {
+		for _, v := range verr.Violations {
+			log.Error(v.String())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// IndexSettings returns the settings the index needs for the configured
+// record processing, such as distinct for split pages, the DocSearch
+// settings for crawled pages or the language settings of a language's index
+func (c *Config) IndexSettings() algoliasearch.Map {
+	settings := algoliasearch.Map{}
+	if c.FromPublic {
+		mergeSettings(settings, c.Crawl.Settings())
+	}
+	if c.Sitemap != nil {
+		mergeSettings(settings, c.Sitemap.Settings())
+	}
+	if c.Split != nil {
+		mergeSettings(settings, c.Split.Settings())
+	}
+	if c.Lang != "" {
+		mergeSettings(settings, LanguageSettings(c.Lang))
+	}
+	return settings
+}
+
+// mergeSettings copies settings into dst, appending to the custom ranking
+// criteria already there instead of replacing them
+func mergeSettings(dst, settings algoliasearch.Map) {
+	for k, v := range settings {
+		if existing, ok := dst[k].([]string); ok && k == "customRanking" {
+			v = append(append([]string{}, existing...), v.([]string)...)
+		}
+		dst[k] = v
+	}
+}
+
+// applyIndexSettings pushes the settings from IndexSettings, if there are any,
+// keeping the custom ranking the index already has
+func (c *Config) applyIndexSettings() error {
+	settings := c.IndexSettings()
+	if len(settings) == 0 {
+		return nil
+	}
+
+	settings, err := keepCustomRanking(c.GetClient(), c.AlgoliaIndexName, settings)
+	if err != nil {
+		return err
+	}
+	log.Info("Applying settings")
+	return PushSettings(c.GetIndex(), settings)
+}
+
 // ClearIndex will clear the search index
 func (c *Config) ClearIndex() error {
 	return ClearIndex(c.GetIndex())
@@ -31,7 +323,7 @@ func (c *Config) ClearIndex() error {
 
 func (c *Config) UploadIndex() error {
 	// Open the upload file and unmarshal it before going further
-	objects, err := c.LoadUploadFile()
+	objects, err := c.loadValidObjects()
 	if err != nil {
 		log.WithError(err).WithField("file", c.UploadFile).Fatal("Failed to load the upload file")
 		return err
@@ -50,5 +342,221 @@ func (c *Config) UploadIndex() error {
 		log.WithError(err).Fatal("Failed to upload new objects")
 		return err
 	}
+
+	if err = c.applyIndexSettings(); err != nil {
+		log.WithError(err).Fatal("Failed to apply settings")
+		return err
+	}
 	return nil
 }
+
+// SyncIndex updates the index incrementally, only adding, updating and
+// deleting the records that differ from the upload file
+func (c *Config) SyncIndex() (SyncResult, error) {
+	objects, err := c.loadValidObjects()
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	result, err := SyncIndex(c.GetIndex(), objects)
+	if err != nil {
+		return result, err
+	}
+	return result, c.applyIndexSettings()
+}
+
+// AtomicUploadIndex uploads the upload file into a temporary index and swaps
+// it in place of the configured index once everything has been indexed
+func (c *Config) AtomicUploadIndex() error {
+	objects, err := c.loadValidObjects()
+	if err != nil {
+		return err
+	}
+
+	return AtomicUploadIndex(c.GetClient(), c.AlgoliaIndexName, objects, c.IndexSettings())
+}
+
+// DiffIndex compares the upload file with the records of the configured index
+func (c *Config) DiffIndex() (ObjectsDiff, error) {
+	local, err := c.LoadObjects()
+	if err != nil {
+		return ObjectsDiff{}, err
+	}
+
+	remote, err := BrowseObjects(c.GetIndex())
+	if err != nil {
+		return ObjectsDiff{}, err
+	}
+
+	return DiffObjects(local, remote)
+}
+
+// PullSettings writes the settings of the configured index to a YAML or JSON file
+func (c *Config) PullSettings(file string) error {
+	settings, err := c.GetSettings()
+	if err != nil {
+		return err
+	}
+	return WriteDataFile(file, settings)
+}
+
+// PushSettings applies the settings from a YAML or JSON file to the configured index
+func (c *Config) PushSettings(file string) error {
+	settings, err := LoadSettingsFile(file)
+	if err != nil {
+		return err
+	}
+	return PushSettings(c.GetIndex(), settings)
+}
+
+// DiffSettings compares the settings in a YAML or JSON file with those of the configured index
+func (c *Config) DiffSettings(file string) ([]SettingChange, error) {
+	local, err := LoadSettingsFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := c.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffSettings(local, remote), nil
+}
+
+// GetSettings returns every setting of the configured index, including the
+// ones the Algolia client does not model
+func (c *Config) GetSettings() (algoliasearch.Map, error) {
+	return GetRawSettings(c.AlgoliaAppID, c.AlgoliaAPIKey, c.AlgoliaIndexName)
+}
+
+// ExportSynonyms writes every synonym of the configured index to a YAML or JSON file
+func (c *Config) ExportSynonyms(file string) (int, error) {
+	synonyms, err := GetSynonyms(c.GetIndex())
+	if err != nil {
+		return 0, err
+	}
+	return len(synonyms), WriteDataFile(file, synonyms)
+}
+
+// ReplaceSynonyms replaces the synonyms of the configured index with those in a YAML or JSON file
+func (c *Config) ReplaceSynonyms(file string, forwardToReplicas bool) (int, error) {
+	synonyms, err := LoadSynonymsFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return len(synonyms), ReplaceSynonyms(c.GetIndex(), synonyms, forwardToReplicas)
+}
+
+// GetSynonyms returns every synonym of the configured index
+func (c *Config) GetSynonyms() ([]algoliasearch.Synonym, error) {
+	return GetSynonyms(c.GetIndex())
+}
+
+// DeleteSynonym deletes a single synonym from the configured index
+func (c *Config) DeleteSynonym(objectID string, forwardToReplicas bool) error {
+	return DeleteSynonym(c.GetIndex(), objectID, forwardToReplicas)
+}
+
+// ExportRules writes every query rule of the configured index to a YAML or JSON file
+func (c *Config) ExportRules(file string) (int, error) {
+	rules, err := GetRules(c.GetIndex())
+	if err != nil {
+		return 0, err
+	}
+	return len(rules), WriteDataFile(file, rules)
+}
+
+// ApplyRules atomically replaces the query rules of the configured index with those in a YAML or JSON file
+func (c *Config) ApplyRules(file string, forwardToReplicas bool) (int, error) {
+	rules, err := LoadRulesFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return len(rules), ReplaceRules(c.GetIndex(), rules, forwardToReplicas)
+}
+
+// DiffRules compares the query rules in a YAML or JSON file with those of the configured index
+func (c *Config) DiffRules(file string) (RulesDiff, error) {
+	local, err := LoadRulesFile(file)
+	if err != nil {
+		return RulesDiff{}, err
+	}
+
+	remote, err := GetRules(c.GetIndex())
+	if err != nil {
+		return RulesDiff{}, err
+	}
+
+	return DiffRules(local, remote), nil
+}
+
+// Backup writes a backup archive of the configured index to file, gzipped
+// when the file name ends in .gz
+func (c *Config) Backup(file string) (count int, err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	buf := bufio.NewWriter(f)
+	var w io.Writer = buf
+	var zw *gzip.Writer
+	if isGzip(file) {
+		zw = gzip.NewWriter(buf)
+		w = zw
+	}
+
+	log.Info("Fetching settings")
+	settings, err := c.GetSettings()
+	if err != nil {
+		return 0, err
+	}
+
+	if count, err = BackupIndex(c.GetIndex(), c.AlgoliaIndexName, settings, w); err != nil {
+		return count, err
+	}
+
+	if zw != nil {
+		if err = zw.Close(); err != nil {
+			return count, err
+		}
+	}
+	return count, buf.Flush()
+}
+
+// Restore recreates the named index from a backup archive file, which is
+// read as gzip when its name ends in .gz
+func (c *Config) Restore(file, name string) (int, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if isGzip(file) {
+		zr, zerr := gzip.NewReader(r)
+		if zerr != nil {
+			return 0, zerr
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	return RestoreIndex(c.GetClient(), name, r)
+}
+
+// TestRelevance runs the relevance cases of a YAML or JSON file against the configured index
+func (c *Config) TestRelevance(file string) ([]RelevanceResult, error) {
+	suite, err := LoadRelevanceSuite(file)
+	if err != nil {
+		return nil, err
+	}
+	return RunRelevanceSuite(c.GetClient(), c.AlgoliaIndexName, suite)
+}
@@ -0,0 +1,462 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	toml "github.com/pelletier/go-toml"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// contentExtensions are the content file types turned into records
+var contentExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".html":     true,
+	".htm":      true,
+}
+
+// siteConfigNames are the Hugo site configuration files, in the order Hugo looks for them
+var siteConfigNames = []string{"config.toml", "config.yaml", "config.yml", "config.json"}
+
+// Site is the part of a Hugo site configuration needed to build permalinks
+type Site struct {
+	BaseURL    string
+	Permalinks map[string]string
+	// DisablePathToLower keeps the case of the content paths in URLs
+	DisablePathToLower bool
+	// KeepHeadings leaves the headings in the content of the records, so that
+	// SplitObjects can split the pages at them
+	KeepHeadings bool
+}
+
+// LoadSite reads the base URL and permalink rules from the Hugo configuration
+// file in dir. A site without a configuration file gets an empty Site.
+func LoadSite(dir string) (Site, error) {
+	site := Site{Permalinks: map[string]string{}}
+
+	for _, name := range siteConfigNames {
+		file := filepath.Join(dir, name)
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+
+		v := viper.New()
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return site, fmt.Errorf("%s: %s", file, err)
+		}
+
+		site.BaseURL = v.GetString("baseurl")
+		site.DisablePathToLower = v.GetBool("disablepathtolower")
+		for section, pattern := range v.GetStringMapString("permalinks") {
+			site.Permalinks[section] = pattern
+		}
+		break
+	}
+
+	return site, nil
+}
+
+// LoadContentDir walks the content directory of a Hugo site and builds a
+// search record for every page that is not a draft. The other content files
+// of a leaf bundle are resources of its page rather than pages. With
+// keepHeadings, the content of the records keeps the headings of the pages.
+func LoadContentDir(siteDir string, keepHeadings bool) ([]algoliasearch.Object, error) {
+	site, err := LoadSite(siteDir)
+	if err != nil {
+		return nil, err
+	}
+	site.KeepHeadings = keepHeadings
+
+	contentDir := filepath.Join(siteDir, "content")
+	var objects []algoliasearch.Object
+	var bundles []string
+	err = filepath.Walk(contentDir, func(file string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if info.IsDir() {
+			if file != contentDir && isLeafBundle(file) {
+				bundles = append(bundles, file+string(filepath.Separator))
+			}
+			return nil
+		}
+		if !contentExtensions[strings.ToLower(filepath.Ext(file))] {
+			return nil
+		}
+		if isBundleResource(file, bundles) {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(contentDir, file)
+		if relErr != nil {
+			return relErr
+		}
+
+		object, pageErr := site.LoadPage(file, filepath.ToSlash(rel))
+		if pageErr != nil {
+			return fmt.Errorf("%s: %s", file, pageErr)
+		}
+		if object != nil {
+			objects = append(objects, object)
+		}
+		return nil
+	})
+
+	return objects, err
+}
+
+// isLeafBundle reports whether dir holds an index content file
+func isLeafBundle(dir string) bool {
+	for ext := range contentExtensions {
+		if _, err := os.Stat(filepath.Join(dir, "index"+ext)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isBundleResource reports whether file is inside one of the leaf bundle
+// directories without being the bundle's own index file
+func isBundleResource(file string, bundles []string) bool {
+	for _, dir := range bundles {
+		if !strings.HasPrefix(file, dir) {
+			continue
+		}
+		rel := file[len(dir):]
+		name := filepath.Base(rel)
+		return rel != name || strings.TrimSuffix(name, filepath.Ext(name)) != "index"
+	}
+	return false
+}
+
+// LoadPage builds a search record from a content file, whose path relative to
+// the content directory is rel. Drafts return a nil record.
+func (s Site) LoadPage(file, rel string) (algoliasearch.Object, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	front, body, err := ParseFrontMatter(b)
+	if err != nil {
+		return nil, err
+	}
+	if cast.ToBool(front["draft"]) {
+		return nil, nil
+	}
+
+	dir, base, section := splitContentPath(rel)
+
+	date, _ := frontMatterDate(front["date"])
+
+	// Pages with headings are left for SplitObjects to convert section by
+	// section when the headings are kept
+	ext := strings.ToLower(path.Ext(rel))
+	isHTML := ext == ".html" || ext == ".htm"
+	var text string
+	switch {
+	case isHTML && s.KeepHeadings && htmlHeading.MatchString(string(body)):
+		text = StripShortcodes(string(body))
+	case isHTML:
+		text = HTMLToText(string(body))
+	case s.KeepHeadings && len(markdownHeadings(string(body))) > 0:
+		text = string(body)
+	default:
+		text = MarkdownToText(string(body))
+	}
+
+	title := cast.ToString(front["title"])
+	relPermalink := s.relPermalink(front, dir, base, section, title, date)
+
+	object := algoliasearch.Object{
+		"objectID":   relPermalink,
+		"title":      title,
+		"permalink":  s.absURL(relPermalink),
+		"uri":        relPermalink,
+		"section":    section,
+		"tags":       stringList(front["tags"]),
+		"categories": stringList(front["categories"]),
+		"content":    text,
+	}
+	if !date.IsZero() {
+		object["date"] = date.Format(time.RFC3339)
+	}
+	if description := cast.ToString(front["description"]); description != "" {
+		object["description"] = description
+	}
+
+	return object, nil
+}
+
+// splitContentPath splits the path of a content file relative to the content
+// directory into its directory, its name without extension and its section
+func splitContentPath(rel string) (dir, base, section string) {
+	dir, name := path.Split(rel)
+	dir = strings.Trim(dir, "/")
+	base = strings.TrimSuffix(name, path.Ext(name))
+	if dir != "" {
+		section = strings.SplitN(dir, "/", 2)[0]
+	}
+	return dir, base, section
+}
+
+// stringList reads a front matter list, returning an empty list when it is missing
+func stringList(v interface{}) []string {
+	if list := cast.ToStringSlice(v); list != nil {
+		return list
+	}
+	return []string{}
+}
+
+// relPermalink works out the site-relative URL of a page the way Hugo does:
+// an explicit url wins, then the permalink pattern for the section, and
+// otherwise the page's path under the content directory, lowercased unless
+// the site disables it
+func (s Site) relPermalink(front map[string]interface{}, dir, base, section, title string, date time.Time) string {
+	if u := cast.ToString(front["url"]); u != "" {
+		return "/" + strings.TrimLeft(u, "/")
+	}
+
+	slug := cast.ToString(front["slug"])
+
+	// A leaf bundle is a page named after its directory
+	if base == "index" && dir != "" {
+		dir, base = path.Split(dir)
+		dir = strings.Trim(dir, "/")
+	}
+
+	// Section index pages are served from their directory
+	if base == "_index" || base == "index" {
+		if dir == "" {
+			return "/"
+		}
+		return s.pathToLower("/" + dir + "/")
+	}
+
+	if pattern, ok := s.Permalinks[section]; ok && section != "" {
+		return s.pathToLower(expandPermalink(pattern, dir, base, section, slug, title, date))
+	}
+
+	if slug != "" {
+		base = slug
+	}
+	return s.pathToLower(path.Clean("/"+dir+"/"+base) + "/")
+}
+
+// pathToLower lowercases a URL path the way Hugo does by default
+func (s Site) pathToLower(p string) string {
+	if s.DisablePathToLower {
+		return p
+	}
+	return strings.ToLower(p)
+}
+
+// absURL prefixes a site-relative URL with the base URL of the site
+func (s Site) absURL(rel string) string {
+	if s.BaseURL == "" {
+		return rel
+	}
+	return strings.TrimRight(s.BaseURL, "/") + rel
+}
+
+// permalinkToken matches the :attribute placeholders in a Hugo permalink pattern
+var permalinkToken = regexp.MustCompile(`:[a-z]+`)
+
+// expandPermalink replaces the placeholders in a Hugo permalink pattern
+func expandPermalink(pattern, dir, base, section, slug, title string, date time.Time) string {
+	result := permalinkToken.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token {
+		case ":year":
+			return date.Format("2006")
+		case ":month":
+			return date.Format("01")
+		case ":monthname":
+			return strings.ToLower(date.Format("January"))
+		case ":day":
+			return date.Format("02")
+		case ":weekday":
+			return fmt.Sprint(int(date.Weekday()))
+		case ":weekdayname":
+			return strings.ToLower(date.Format("Monday"))
+		case ":yearday":
+			return fmt.Sprint(date.YearDay())
+		case ":section":
+			return section
+		case ":sections":
+			return dir
+		case ":title":
+			return Urlize(title)
+		case ":slug":
+			if slug != "" {
+				return Urlize(slug)
+			}
+			return Urlize(title)
+		case ":filename":
+			return base
+		}
+		return token
+	})
+
+	if !strings.HasPrefix(result, "/") {
+		result = "/" + result
+	}
+	return result
+}
+
+// Urlize turns a title into a URL path segment the way Hugo does
+func Urlize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// ParseFrontMatter splits a content file into its front matter, which may be
+// YAML (---), TOML (+++) or JSON ({...}), and its body
+func ParseFrontMatter(b []byte) (map[string]interface{}, []byte, error) {
+	front := map[string]interface{}{}
+	trimmed := bytes.TrimLeft(b, "\ufeff \t\r\n")
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("---")):
+		raw, body, ok := splitFrontMatter(trimmed, "---")
+		if !ok {
+			return nil, nil, fmt.Errorf("unterminated YAML front matter")
+		}
+		var v interface{}
+		if err := yaml.Unmarshal(raw, &v); err != nil {
+			return nil, nil, err
+		}
+		if m, ok := jsonValue(v).(map[string]interface{}); ok {
+			front = m
+		}
+		return front, body, nil
+
+	case bytes.HasPrefix(trimmed, []byte("+++")):
+		raw, body, ok := splitFrontMatter(trimmed, "+++")
+		if !ok {
+			return nil, nil, fmt.Errorf("unterminated TOML front matter")
+		}
+		tree, err := toml.LoadBytes(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tree.ToMap(), body, nil
+
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		r := bytes.NewReader(trimmed)
+		dec := json.NewDecoder(r)
+		if err := dec.Decode(&front); err != nil {
+			return nil, nil, err
+		}
+		body, err := ioutil.ReadAll(io.MultiReader(dec.Buffered(), r))
+		return front, body, err
+	}
+
+	return front, b, nil
+}
+
+// splitFrontMatter returns the text between the opening delimiter line and
+// the next line holding only the delimiter, and the text after it
+func splitFrontMatter(b []byte, delim string) ([]byte, []byte, bool) {
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	offset := len(lines[0])
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(string(line)) == delim {
+			return b[len(lines[0]):offset], b[offset+len(line):], true
+		}
+		offset += len(line)
+	}
+	return nil, nil, false
+}
+
+// frontMatterDate reads a date from front matter, where it may be a TOML
+// datetime or a string in one of the layouts Hugo accepts
+func frontMatterDate(v interface{}) (time.Time, error) {
+	if v == nil {
+		return time.Time{}, fmt.Errorf("no date")
+	}
+	return cast.ToTimeE(v)
+}
+
+var (
+	mdCodeFence  = regexp.MustCompile("(?s)```.*?```|~~~.*?~~~")
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdRefLink    = regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`)
+	mdLinkDef    = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s*\S+.*$`)
+	mdHeading    = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s*`)
+	mdBlockquote = regexp.MustCompile(`(?m)^\s*>\s?`)
+	mdListMarker = regexp.MustCompile(`(?m)^\s*([-*+]|\d+\.)\s+`)
+	mdRule       = regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$`)
+	mdEmphasis   = regexp.MustCompile(`(\*{1,3}|~~|` + "`" + `)`)
+	mdUnderscore = regexp.MustCompile(`_+`)
+)
+
+// MarkdownToText strips Markdown syntax, HTML and Hugo shortcodes from a page
+// body, leaving plain text suitable for searching
+func MarkdownToText(s string) string {
+	s = mdCodeFence.ReplaceAllString(s, " ")
+	s = StripShortcodes(s)
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdRefLink.ReplaceAllString(s, "$1")
+	s = mdLinkDef.ReplaceAllString(s, "")
+	s = mdHeading.ReplaceAllString(s, "")
+	s = mdBlockquote.ReplaceAllString(s, "")
+	s = mdListMarker.ReplaceAllString(s, "")
+	s = mdRule.ReplaceAllString(s, "")
+	s = mdEmphasis.ReplaceAllString(s, "")
+	s = stripUnderscores(s)
+	return HTMLToText(s)
+}
+
+// stripUnderscores removes the underscores that delimit emphasis, keeping the
+// ones inside words such as my_var_name
+func stripUnderscores(s string) string {
+	matches := mdUnderscore.FindAllStringIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		before, _ := utf8.DecodeLastRuneInString(s[:m[0]])
+		after, _ := utf8.DecodeRuneInString(s[m[1]:])
+		if isWordRune(before) && isWordRune(after) {
+			continue
+		}
+		b.WriteString(s[last:m[0]])
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
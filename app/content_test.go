@@ -0,0 +1,168 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRelPermalink(t *testing.T) {
+	site := Site{Permalinks: map[string]string{"posts": "/:year/:filename/"}}
+	date := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		rel   string
+		front map[string]interface{}
+		want  string
+	}{
+		{"_index.md", nil, "/"},
+		{"docs/_index.md", nil, "/docs/"},
+		{"docs/Getting-Started.md", nil, "/docs/getting-started/"},
+		{"docs/install.md", map[string]interface{}{"slug": "Setup"}, "/docs/setup/"},
+		{"docs/install.md", map[string]interface{}{"url": "/Custom/Path/"}, "/Custom/Path/"},
+		{"docs/Guide/index.md", nil, "/docs/guide/"},
+		{"docs/guide/index.md", map[string]interface{}{"slug": "tour"}, "/docs/tour/"},
+		{"About/index.md", nil, "/about/"},
+		{"posts/Hello.md", nil, "/2018/hello/"},
+		{"posts/My-Bundle/index.md", nil, "/2018/my-bundle/"},
+		{"posts/_index.md", nil, "/posts/"},
+	}
+
+	for _, tt := range tests {
+		dir, base, section := splitContentPath(tt.rel)
+		got := site.relPermalink(tt.front, dir, base, section, "", date)
+		if got != tt.want {
+			t.Errorf("relPermalink(%q, %v) = %q, want %q", tt.rel, tt.front, got, tt.want)
+		}
+	}
+
+	site.DisablePathToLower = true
+	dir, base, section := splitContentPath("docs/Getting-Started.md")
+	if got := site.relPermalink(nil, dir, base, section, "", date); got != "/docs/Getting-Started/" {
+		t.Errorf("relPermalink with disablePathToLower = %q, want %q", got, "/docs/Getting-Started/")
+	}
+}
+
+func TestLoadContentDirSkipsBundleResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"content/_index.md":                   "---\ntitle: Home\n---\n",
+		"content/posts/plain.md":              "---\ntitle: Plain\n---\n",
+		"content/posts/bundle/index.md":       "---\ntitle: Bundle\n---\n",
+		"content/posts/bundle/notes.md":       "---\ntitle: Notes\n---\n",
+		"content/posts/bundle/extra/more.md":  "---\ntitle: More\n---\n",
+		"content/posts/bundle/image.png":      "",
+		"content/docs/_index.md":              "---\ntitle: Docs\n---\n",
+		"content/docs/setup.md":               "---\ntitle: Setup\n---\n",
+		"content/docs/draft.md":               "---\ntitle: Draft\ndraft: true\n---\n",
+		"content/docs/branch/_index.md":       "---\ntitle: Branch\n---\n",
+		"content/docs/branch/child.md":        "---\ntitle: Child\n---\n",
+		"content/docs/branch/leaf/index.html": "---\ntitle: Leaf\n---\n<p>leaf</p>",
+	}
+	for name, body := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(file, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	objects, err := LoadContentDir(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, o := range objects {
+		got = append(got, o["objectID"].(string))
+	}
+	sort.Strings(got)
+	want := []string{"/", "/docs/", "/docs/branch/", "/docs/branch/child/", "/docs/branch/leaf/", "/docs/setup/", "/posts/bundle/", "/posts/plain/"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMarkdownToText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Set my_var_name to __init__", "Set my_var_name to init"},
+		{"This is _important_ and __bold__", "This is important and bold"},
+		{"___both___ and snake_case_", "both and snake_case"},
+		{"*em* **strong** ~~gone~~ `code`", "em strong gone code"},
+		{"## Heading\n\n- [a link](http://example.com) and ![alt](x.png)", "Heading a link and alt"},
+		{"```\nfenced\n```\nafter", "after"},
+		{"日本_語 _日本語_", "日本_語 日本語"},
+	}
+
+	for _, tt := range tests {
+		if got := MarkdownToText(tt.in); got != tt.want {
+			t.Errorf("MarkdownToText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadObjectsSplitsContentPages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"content/docs/install.md": "---\ntitle: Install\n---\nIntro with **bold**\n\n## Linux\n\nRun `make` {{< note >}}\n\n```sh\n# not a heading\n```\n\n## macOS {#mac}\n\nUse brew",
+		"content/docs/plain.md":   "---\ntitle: Plain\n---\nNo *headings* here",
+		"content/docs/page.html":  "---\ntitle: Page\n---\n<p>Top</p><h2 id=\"more\">More</h2><p>Rest {{< x >}}</p>",
+	}
+	for name, body := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(file, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := Config{SiteDir: dir, FromContent: true, Split: &SplitConfig{}}
+	objects, err := c.LoadObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for _, o := range objects {
+		got[o["objectID"].(string)] = o["content"].(string)
+	}
+	want := map[string]string{
+		"/docs/install/#0":     "Intro with bold",
+		"/docs/install/#linux": "Run make",
+		"/docs/install/#mac":   "Use brew",
+		"/docs/plain/#0":       "No headings here",
+		"/docs/page/#0":        "Top",
+		"/docs/page/#more":     "Rest",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for id, content := range want {
+		if got[id] != content {
+			t.Errorf("record %s content = %q, want %q", id, got[id], content)
+		}
+	}
+}
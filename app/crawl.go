@@ -0,0 +1,376 @@
+package app
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+)
+
+// crawlLevels are the hierarchy levels of a DocSearch record, from the highest
+var crawlLevels = []string{"lvl0", "lvl1", "lvl2", "lvl3", "lvl4", "lvl5", "lvl6"}
+
+// defaultCrawlSelectors are used when no selectors are configured
+var defaultCrawlSelectors = map[string]string{
+	"lvl1":    "h1",
+	"lvl2":    "h2",
+	"lvl3":    "h3",
+	"lvl4":    "h4",
+	"lvl5":    "h5",
+	"lvl6":    "h6",
+	"content": "p, li",
+}
+
+// CrawlConfig configures how the rendered HTML of a site is turned into
+// DocSearch records
+type CrawlConfig struct {
+	// Dir holds the rendered site, "public" under the site directory by default
+	Dir string `mapstructure:"dir"`
+	// BaseURL prefixes the page paths, the baseURL of the site by default
+	BaseURL string `mapstructure:"base_url"`
+	// Selectors maps lvl0 to lvl6 and content to CSS selectors
+	Selectors map[string]string `mapstructure:"selectors"`
+	// Global lists the levels whose first match on a page applies to the
+	// whole page, lvl0 by default
+	Global []string `mapstructure:"global"`
+	// Defaults are the values of levels that match nothing on a page
+	Defaults map[string]string `mapstructure:"defaults"`
+	// Exclude lists path patterns, relative to Dir, of pages to skip
+	Exclude []string `mapstructure:"exclude"`
+	// ExcludeSelectors matches elements to ignore, such as navigation
+	ExcludeSelectors string `mapstructure:"exclude_selectors"`
+}
+
+// crawler holds the compiled selectors of a CrawlConfig
+type crawler struct {
+	config    CrawlConfig
+	baseURL   string
+	levels    []cssSelector
+	content   cssSelector
+	exclude   cssSelector
+	global    map[string]bool
+	objectIDs map[string]int
+}
+
+// Settings returns the index settings DocSearch front ends expect
+func (c *CrawlConfig) Settings() algoliasearch.Map {
+	searchable := make([]string, 0, len(crawlLevels)+1)
+	for _, level := range crawlLevels {
+		searchable = append(searchable, "unordered(hierarchy."+level+")")
+	}
+	searchable = append(searchable, "content")
+
+	return algoliasearch.Map{
+		"searchableAttributes":                    searchable,
+		"attributesForFaceting":                   []string{"type"},
+		"attributesToRetrieve":                    []string{"hierarchy", "content", "anchor", "url", "url_without_anchor", "type"},
+		"attributesToHighlight":                   []string{"hierarchy", "content"},
+		"attributesToSnippet":                     []string{"content:10"},
+		"camelCaseAttributes":                     []string{"hierarchy", "content"},
+		"customRanking":                           []string{"desc(weight.pageRank)", "desc(weight.level)", "asc(weight.position)"},
+		"attributeForDistinct":                    "url",
+		"distinct":                                true,
+		"advancedSyntax":                          true,
+		"minWordSizefor1Typo":                     3,
+		"minWordSizefor2Typos":                    7,
+		"allowTyposOnNumericTokens":               false,
+		"minProximity":                            1,
+		"ignorePlurals":                           true,
+		"removeWordsIfNoResults":                  "allOptional",
+		"attributeCriteriaComputedByMinProximity": true,
+	}
+}
+
+// CrawlSite builds DocSearch records from every HTML page of the rendered site
+func (c *CrawlConfig) CrawlSite(siteDir string) ([]algoliasearch.Object, error) {
+	cr, err := c.compile(siteDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := c.Dir
+	if dir == "" {
+		dir = "public"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(siteDir, dir)
+	}
+
+	var objects []algoliasearch.Object
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		ext := strings.ToLower(filepath.Ext(file))
+		if info.IsDir() || (ext != ".html" && ext != ".htm") {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(dir, file)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		if cr.excluded(rel) {
+			return nil
+		}
+
+		b, readErr := ioutil.ReadFile(file)
+		if readErr != nil {
+			return readErr
+		}
+		records := cr.pageRecords(pageURL(rel), parseHTML(string(b)))
+		log.Debugf("%s: %d records", rel, len(records))
+		objects = append(objects, records...)
+		return nil
+	})
+
+	return objects, err
+}
+
+func (c *CrawlConfig) compile(siteDir string) (*crawler, error) {
+	selectors := c.Selectors
+	if len(selectors) == 0 {
+		selectors = defaultCrawlSelectors
+	}
+	for key := range selectors {
+		if key != "content" && !isCrawlLevel(key) {
+			return nil, fmt.Errorf("crawl: unknown selector %q, expected lvl0 to lvl6 or content", key)
+		}
+	}
+
+	cr := &crawler{config: *c, global: map[string]bool{}, objectIDs: map[string]int{}}
+	for _, level := range crawlLevels {
+		var sel cssSelector
+		if s := strings.TrimSpace(selectors[level]); s != "" {
+			var err error
+			if sel, err = parseSelector(s); err != nil {
+				return nil, fmt.Errorf("crawl: %s: %s", level, err)
+			}
+		}
+		cr.levels = append(cr.levels, sel)
+	}
+
+	var err error
+	if s := strings.TrimSpace(selectors["content"]); s != "" {
+		if cr.content, err = parseSelector(s); err != nil {
+			return nil, fmt.Errorf("crawl: content: %s", err)
+		}
+	}
+	if strings.TrimSpace(c.ExcludeSelectors) != "" {
+		if cr.exclude, err = parseSelector(c.ExcludeSelectors); err != nil {
+			return nil, fmt.Errorf("crawl: exclude_selectors: %s", err)
+		}
+	}
+
+	global := c.Global
+	if global == nil {
+		global = []string{"lvl0"}
+	}
+	for _, level := range global {
+		if !isCrawlLevel(level) {
+			return nil, fmt.Errorf("crawl: unknown global level %q", level)
+		}
+		cr.global[level] = true
+	}
+
+	cr.baseURL = c.BaseURL
+	if cr.baseURL == "" {
+		var site Site
+		if site, err = LoadSite(siteDir); err != nil {
+			return nil, err
+		}
+		cr.baseURL = site.BaseURL
+	}
+	cr.baseURL = strings.TrimRight(cr.baseURL, "/")
+
+	return cr, nil
+}
+
+func isCrawlLevel(s string) bool {
+	for _, level := range crawlLevels {
+		if s == level {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded reports whether a page, or a directory it is in, matches an exclude pattern
+func (cr *crawler) excluded(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for _, pattern := range cr.config.Exclude {
+		pattern = strings.Trim(pattern, "/")
+		for i := range parts {
+			if ok, _ := path.Match(pattern, strings.Join(parts[:i+1], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pageURL is the site-relative URL a page is served from
+func pageURL(rel string) string {
+	if rel == "index.html" {
+		return "/"
+	}
+	if strings.HasSuffix(rel, "/index.html") {
+		return "/" + strings.TrimSuffix(rel, "index.html")
+	}
+	return "/" + rel
+}
+
+// pageRecords walks a page in document order, emitting a record for every
+// heading and every block of content along with the headings above it
+func (cr *crawler) pageRecords(rel string, doc *htmlNode) []algoliasearch.Object {
+	if skipPage(doc) {
+		return nil
+	}
+
+	if cr.exclude != nil {
+		for _, n := range doc.querySelectorAll(cr.exclude) {
+			n.remove()
+		}
+	}
+
+	hierarchy := make(map[string]interface{}, len(crawlLevels))
+	for i, level := range crawlLevels {
+		hierarchy[level] = nil
+		if v := cr.config.Defaults[level]; v != "" {
+			hierarchy[level] = v
+		}
+		if cr.global[level] && cr.levels[i] != nil {
+			if found := doc.querySelectorAll(cr.levels[i]); len(found) > 0 {
+				if text := found[0].textContent(); text != "" {
+					hierarchy[level] = text
+				}
+			}
+		}
+	}
+
+	url := cr.baseURL + rel
+	anchor := ""
+	var records []algoliasearch.Object
+	doc.walk(func(n *htmlNode) bool {
+		for i, level := range crawlLevels {
+			if cr.global[level] || cr.levels[i] == nil || !cr.levels[i].matches(n) {
+				continue
+			}
+			text := n.textContent()
+			if text == "" {
+				return false
+			}
+
+			hierarchy[level] = text
+			for _, deeper := range crawlLevels[i+1:] {
+				if !cr.global[deeper] {
+					hierarchy[deeper] = nil
+				}
+			}
+			anchor = elementAnchor(n)
+			records = append(records, cr.record(url, anchor, level, 100-10*i, len(records), hierarchy, nil))
+			return false
+		}
+
+		if cr.content.matches(n) {
+			if text := n.textContent(); text != "" {
+				records = append(records, cr.record(url, anchor, "content", 0, len(records), hierarchy, text))
+			}
+			return false
+		}
+		return true
+	})
+
+	return records
+}
+
+// skipPage reports whether a page is an alias redirect or asks not to be indexed
+func skipPage(doc *htmlNode) bool {
+	for _, meta := range doc.querySelectorAll(cssSelector{{{tag: "meta"}}}) {
+		if strings.EqualFold(meta.attrs["http-equiv"], "refresh") {
+			return true
+		}
+		if strings.EqualFold(meta.attrs["name"], "robots") && strings.Contains(strings.ToLower(meta.attrs["content"]), "noindex") {
+			return true
+		}
+	}
+	return false
+}
+
+// elementAnchor finds the id a link to the heading would use
+func elementAnchor(n *htmlNode) string {
+	if id := n.attrs["id"]; id != "" {
+		return id
+	}
+
+	anchor := ""
+	n.walk(func(c *htmlNode) bool {
+		if anchor != "" {
+			return false
+		}
+		if id := c.attrs["id"]; id != "" {
+			anchor = id
+		} else if c.tag == "a" && c.attrs["name"] != "" {
+			anchor = c.attrs["name"]
+		}
+		return anchor == ""
+	})
+	return anchor
+}
+
+func (cr *crawler) record(url, anchor, typ string, level, position int, hierarchy map[string]interface{}, content interface{}) algoliasearch.Object {
+	h := make(map[string]interface{}, len(hierarchy))
+	for k, v := range hierarchy {
+		h[k] = v
+	}
+
+	fullURL := url
+	var anchorValue interface{}
+	if anchor != "" {
+		fullURL += "#" + anchor
+		anchorValue = anchor
+	}
+
+	// The objectID is derived from the record itself so it is stable
+	// between runs, with a counter for records that are identical
+	sum := sha1.Sum([]byte(fmt.Sprint(fullURL, typ, content, hierarchyKey(h))))
+	id := hex.EncodeToString(sum[:10])
+	if n := cr.objectIDs[id]; n > 0 {
+		cr.objectIDs[id]++
+		id = fmt.Sprintf("%s-%d", id, n)
+	} else {
+		cr.objectIDs[id] = 1
+	}
+
+	return algoliasearch.Object{
+		"objectID":           id,
+		"url":                fullURL,
+		"url_without_anchor": url,
+		"anchor":             anchorValue,
+		"type":               typ,
+		"content":            content,
+		"hierarchy":          h,
+		"weight": map[string]interface{}{
+			"pageRank": 0,
+			"level":    level,
+			"position": position,
+		},
+	}
+}
+
+// hierarchyKey renders a hierarchy in level order
+func hierarchyKey(h map[string]interface{}) string {
+	parts := make([]string, len(crawlLevels))
+	for i, level := range crawlLevels {
+		parts[i] = fmt.Sprint(h[level])
+	}
+	return strings.Join(parts, "\x00")
+}
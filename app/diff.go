@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// ObjectChange describes a record that exists on both sides but differs
+type ObjectChange struct {
+	ObjectID   string   `json:"objectID"`
+	Attributes []string `json:"attributes"`
+}
+
+// ObjectsDiff lists the records that would be added, removed or modified to
+// make the index match a set of local objects
+type ObjectsDiff struct {
+	Added    []string       `json:"added"`
+	Removed  []string       `json:"removed"`
+	Modified []ObjectChange `json:"modified"`
+}
+
+// Empty reports whether the local objects and the index hold the same records
+func (d ObjectsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// DiffObjects compares local objects against remote ones by objectID and
+// reports which attributes changed for each modified record
+func DiffObjects(local, remote []algoliasearch.Object) (ObjectsDiff, error) {
+	diff := ObjectsDiff{Added: []string{}, Removed: []string{}, Modified: []ObjectChange{}}
+
+	remoteByID := make(map[string]algoliasearch.Object, len(remote))
+	for _, o := range remote {
+		id, err := o.ObjectID()
+		if err != nil {
+			return diff, err
+		}
+		remoteByID[id] = o
+	}
+
+	seen := make(map[string]bool, len(local))
+	for i, o := range local {
+		id, err := o.ObjectID()
+		if err != nil {
+			return diff, fmt.Errorf("object %d: %s", i, err)
+		}
+		seen[id] = true
+
+		r, exists := remoteByID[id]
+		if !exists {
+			diff.Added = append(diff.Added, id)
+			continue
+		}
+
+		if attrs := changedAttributes(o, r); len(attrs) > 0 {
+			diff.Modified = append(diff.Modified, ObjectChange{ObjectID: id, Attributes: attrs})
+		}
+	}
+
+	for id := range remoteByID {
+		if !seen[id] {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Modified, func(i, j int) bool { return diff.Modified[i].ObjectID < diff.Modified[j].ObjectID })
+	return diff, nil
+}
+
+// changedAttributes returns the sorted names of the attributes whose values
+// differ between two versions of a record
+func changedAttributes(local, remote algoliasearch.Object) []string {
+	names := map[string]bool{}
+	for k := range local {
+		names[k] = true
+	}
+	for k := range remote {
+		names[k] = true
+	}
+
+	var changed []string
+	for name := range names {
+		if isResponseAttribute(name) {
+			continue
+		}
+
+		l, lok := local[name]
+		r, rok := remote[name]
+		if lok != rok || !reflect.DeepEqual(normalizeValue(l), normalizeValue(r)) {
+			changed = append(changed, name)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestDiffObjects(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote []algoliasearch.Object
+		want          ObjectsDiff
+	}{
+		{
+			"empty",
+			nil, nil,
+			ObjectsDiff{Added: []string{}, Removed: []string{}, Modified: []ObjectChange{}},
+		},
+		{
+			"same records, different number types and response attributes",
+			[]algoliasearch.Object{{"objectID": "a", "n": 1, "tags": []string{"x"}}},
+			[]algoliasearch.Object{{"objectID": "a", "n": float64(1), "tags": []interface{}{"x"}, "_snippetResult": map[string]interface{}{}}},
+			ObjectsDiff{Added: []string{}, Removed: []string{}, Modified: []ObjectChange{}},
+		},
+		{
+			"added, removed and modified, sorted",
+			[]algoliasearch.Object{
+				{"objectID": "c", "title": "C"},
+				{"objectID": "m2", "title": "new", "draft": true},
+				{"objectID": "b", "title": "B"},
+				{"objectID": "m1", "title": "T", "tags": []interface{}{"a", "b"}},
+			},
+			[]algoliasearch.Object{
+				{"objectID": "m1", "title": "T", "tags": []interface{}{"b", "a"}},
+				{"objectID": "z"},
+				{"objectID": "m2", "title": "old", "summary": "s"},
+				{"objectID": "y"},
+			},
+			ObjectsDiff{
+				Added:   []string{"b", "c"},
+				Removed: []string{"y", "z"},
+				Modified: []ObjectChange{
+					{ObjectID: "m1", Attributes: []string{"tags"}},
+					{ObjectID: "m2", Attributes: []string{"draft", "summary", "title"}},
+				},
+			},
+		},
+		{
+			"null is not missing",
+			[]algoliasearch.Object{{"objectID": "a", "summary": nil}},
+			[]algoliasearch.Object{{"objectID": "a"}},
+			ObjectsDiff{Added: []string{}, Removed: []string{}, Modified: []ObjectChange{{ObjectID: "a", Attributes: []string{"summary"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		diff, err := DiffObjects(tt.local, tt.remote)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(diff, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, diff, tt.want)
+		}
+		if diff.Empty() != (len(tt.want.Added)+len(tt.want.Removed)+len(tt.want.Modified) == 0) {
+			t.Errorf("%s: Empty() = %v", tt.name, diff.Empty())
+		}
+	}
+}
+
+func TestDiffObjectsErrors(t *testing.T) {
+	if _, err := DiffObjects([]algoliasearch.Object{{"objectID": "a"}, {"title": "T"}}, nil); err == nil || !strings.Contains(err.Error(), "object 1") {
+		t.Errorf("local without objectID: error = %v", err)
+	}
+	if _, err := DiffObjects(nil, []algoliasearch.Object{{"title": "T"}}); err == nil {
+		t.Error("remote without objectID: no error")
+	}
+}
@@ -0,0 +1,464 @@
+package app
+
+import (
+	"encoding/json"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+)
+
+// DryRunOperation is a mutating call that was recorded instead of being sent to Algolia
+type DryRunOperation struct {
+	Index   string `json:"index"`
+	Action  string `json:"action"`
+	Objects int    `json:"objects"`
+	Bytes   int    `json:"bytes"`
+}
+
+// DryRunRecorder collects the operations that a dry run would have sent
+type DryRunRecorder struct {
+	Operations []DryRunOperation
+}
+
+// record logs and remembers an operation along with the size of its JSON payload
+func (r *DryRunRecorder) record(index, action string, objects int, payload interface{}) {
+	size := 0
+	if payload != nil {
+		if b, err := json.Marshal(payload); err == nil {
+			size = len(b)
+		}
+	}
+
+	op := DryRunOperation{Index: index, Action: action, Objects: objects, Bytes: size}
+	r.Operations = append(r.Operations, op)
+	log.WithFields(log.Fields{
+		"index":   op.Index,
+		"objects": op.Objects,
+		"bytes":   op.Bytes,
+	}).Infof("[dry run] %s", op.Action)
+}
+
+// dryRunClient is an algoliasearch.Client whose mutating calls are recorded
+// rather than sent. Read-only calls go to the wrapped client. Every mutating
+// call is overridden along with its WithRequestOptions variant, since the
+// wrapped client would otherwise send it.
+type dryRunClient struct {
+	algoliasearch.Client
+	recorder *DryRunRecorder
+}
+
+// NewDryRunClient wraps client so that its mutating calls are only recorded
+func NewDryRunClient(client algoliasearch.Client, recorder *DryRunRecorder) algoliasearch.Client {
+	return &dryRunClient{Client: client, recorder: recorder}
+}
+
+func (c *dryRunClient) InitIndex(name string) algoliasearch.Index {
+	return NewDryRunIndex(c.Client.InitIndex(name), name, c.recorder)
+}
+
+func (c *dryRunClient) MoveIndex(source, destination string) (algoliasearch.UpdateTaskRes, error) {
+	return c.MoveIndexWithRequestOptions(source, destination, nil)
+}
+
+func (c *dryRunClient) MoveIndexWithRequestOptions(source, destination string, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	c.recorder.record(source, "move to "+destination, 0, nil)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (c *dryRunClient) CopyIndex(source, destination string) (algoliasearch.UpdateTaskRes, error) {
+	return c.CopyIndexWithRequestOptions(source, destination, nil)
+}
+
+func (c *dryRunClient) CopyIndexWithRequestOptions(source, destination string, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	c.recorder.record(source, "copy to "+destination, 0, nil)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (c *dryRunClient) ScopedCopyIndex(source, destination string, scopes []string) (algoliasearch.UpdateTaskRes, error) {
+	return c.ScopedCopyIndexWithRequestOptions(source, destination, scopes, nil)
+}
+
+func (c *dryRunClient) ScopedCopyIndexWithRequestOptions(source, destination string, scopes []string, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	c.recorder.record(source, "copy to "+destination, 0, scopes)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (c *dryRunClient) DeleteIndex(name string) (algoliasearch.DeleteTaskRes, error) {
+	return c.DeleteIndexWithRequestOptions(name, nil)
+}
+
+func (c *dryRunClient) DeleteIndexWithRequestOptions(name string, opts *algoliasearch.RequestOptions) (algoliasearch.DeleteTaskRes, error) {
+	c.recorder.record(name, "delete index", 0, nil)
+	return algoliasearch.DeleteTaskRes{}, nil
+}
+
+func (c *dryRunClient) ClearIndex(name string) (algoliasearch.UpdateTaskRes, error) {
+	return c.ClearIndexWithRequestOptions(name, nil)
+}
+
+func (c *dryRunClient) ClearIndexWithRequestOptions(name string, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	c.recorder.record(name, "clear", 0, nil)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (c *dryRunClient) Batch(operations []algoliasearch.BatchOperationIndexed) (algoliasearch.MultipleBatchRes, error) {
+	return c.BatchWithRequestOptions(operations, nil)
+}
+
+func (c *dryRunClient) BatchWithRequestOptions(operations []algoliasearch.BatchOperationIndexed, opts *algoliasearch.RequestOptions) (algoliasearch.MultipleBatchRes, error) {
+	c.recorder.record("*", "batch", len(operations), operations)
+	return algoliasearch.MultipleBatchRes{}, nil
+}
+
+func (c *dryRunClient) AddUserKey(ACL []string, params algoliasearch.Map) (algoliasearch.AddKeyRes, error) {
+	return c.AddAPIKeyWithRequestOptions(ACL, params, nil)
+}
+
+func (c *dryRunClient) AddAPIKey(ACL []string, params algoliasearch.Map) (algoliasearch.AddKeyRes, error) {
+	return c.AddAPIKeyWithRequestOptions(ACL, params, nil)
+}
+
+func (c *dryRunClient) AddAPIKeyWithRequestOptions(ACL []string, params algoliasearch.Map, opts *algoliasearch.RequestOptions) (algoliasearch.AddKeyRes, error) {
+	c.recorder.record("*", "addAPIKey", 0, nil)
+	return algoliasearch.AddKeyRes{}, nil
+}
+
+func (c *dryRunClient) UpdateUserKey(key string, params algoliasearch.Map) (algoliasearch.UpdateKeyRes, error) {
+	return c.UpdateAPIKeyWithRequestOptions(key, params, nil)
+}
+
+func (c *dryRunClient) UpdateAPIKey(key string, params algoliasearch.Map) (algoliasearch.UpdateKeyRes, error) {
+	return c.UpdateAPIKeyWithRequestOptions(key, params, nil)
+}
+
+func (c *dryRunClient) UpdateAPIKeyWithRequestOptions(key string, params algoliasearch.Map, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateKeyRes, error) {
+	c.recorder.record("*", "updateAPIKey", 0, nil)
+	return algoliasearch.UpdateKeyRes{}, nil
+}
+
+func (c *dryRunClient) DeleteUserKey(key string) (algoliasearch.DeleteRes, error) {
+	return c.DeleteAPIKeyWithRequestOptions(key, nil)
+}
+
+func (c *dryRunClient) DeleteAPIKey(key string) (algoliasearch.DeleteRes, error) {
+	return c.DeleteAPIKeyWithRequestOptions(key, nil)
+}
+
+func (c *dryRunClient) DeleteAPIKeyWithRequestOptions(key string, opts *algoliasearch.RequestOptions) (algoliasearch.DeleteRes, error) {
+	c.recorder.record("*", "deleteAPIKey", 0, nil)
+	return algoliasearch.DeleteRes{}, nil
+}
+
+// dryRunIndex is an algoliasearch.Index whose mutating calls are recorded
+// rather than sent. Read-only calls go to the wrapped index.
+type dryRunIndex struct {
+	algoliasearch.Index
+	name     string
+	recorder *DryRunRecorder
+}
+
+// NewDryRunIndex wraps index so that its mutating calls are only recorded
+func NewDryRunIndex(index algoliasearch.Index, name string, recorder *DryRunRecorder) algoliasearch.Index {
+	return &dryRunIndex{Index: index, name: name, recorder: recorder}
+}
+
+// WaitTask returns immediately, since no task was ever created
+func (i *dryRunIndex) WaitTask(taskID int) error {
+	return nil
+}
+
+func (i *dryRunIndex) WaitTaskWithRequestOptions(taskID int, opts *algoliasearch.RequestOptions) error {
+	return nil
+}
+
+func (i *dryRunIndex) Delete() (algoliasearch.DeleteTaskRes, error) {
+	return i.DeleteWithRequestOptions(nil)
+}
+
+func (i *dryRunIndex) DeleteWithRequestOptions(opts *algoliasearch.RequestOptions) (algoliasearch.DeleteTaskRes, error) {
+	i.recorder.record(i.name, "delete index", 0, nil)
+	return algoliasearch.DeleteTaskRes{}, nil
+}
+
+func (i *dryRunIndex) Clear() (algoliasearch.UpdateTaskRes, error) {
+	return i.ClearWithRequestOptions(nil)
+}
+
+func (i *dryRunIndex) ClearWithRequestOptions(opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "clear", 0, nil)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) Copy(name string) (algoliasearch.UpdateTaskRes, error) {
+	return i.CopyWithRequestOptions(name, nil)
+}
+
+func (i *dryRunIndex) CopyWithRequestOptions(name string, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "copy to "+name, 0, nil)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) ScopedCopy(name string, scopes []string) (algoliasearch.UpdateTaskRes, error) {
+	return i.ScopedCopyWithRequestOptions(name, scopes, nil)
+}
+
+func (i *dryRunIndex) ScopedCopyWithRequestOptions(name string, scopes []string, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "copy to "+name, 0, scopes)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) Move(name string) (algoliasearch.UpdateTaskRes, error) {
+	return i.MoveWithRequestOptions(name, nil)
+}
+
+func (i *dryRunIndex) MoveWithRequestOptions(name string, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "move to "+name, 0, nil)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) SetSettings(settings algoliasearch.Map) (algoliasearch.UpdateTaskRes, error) {
+	return i.SetSettingsWithRequestOptions(settings, nil)
+}
+
+func (i *dryRunIndex) SetSettingsWithRequestOptions(settings algoliasearch.Map, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "setSettings", len(settings), settings)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) AddUserKey(ACL []string, params algoliasearch.Map) (algoliasearch.AddKeyRes, error) {
+	return i.AddAPIKeyWithRequestOptions(ACL, params, nil)
+}
+
+func (i *dryRunIndex) AddAPIKey(ACL []string, params algoliasearch.Map) (algoliasearch.AddKeyRes, error) {
+	return i.AddAPIKeyWithRequestOptions(ACL, params, nil)
+}
+
+func (i *dryRunIndex) AddAPIKeyWithRequestOptions(ACL []string, params algoliasearch.Map, opts *algoliasearch.RequestOptions) (algoliasearch.AddKeyRes, error) {
+	i.recorder.record(i.name, "addAPIKey", 0, nil)
+	return algoliasearch.AddKeyRes{}, nil
+}
+
+func (i *dryRunIndex) UpdateUserKey(key string, params algoliasearch.Map) (algoliasearch.UpdateKeyRes, error) {
+	return i.UpdateAPIKeyWithRequestOptions(key, params, nil)
+}
+
+func (i *dryRunIndex) UpdateAPIKey(key string, params algoliasearch.Map) (algoliasearch.UpdateKeyRes, error) {
+	return i.UpdateAPIKeyWithRequestOptions(key, params, nil)
+}
+
+func (i *dryRunIndex) UpdateAPIKeyWithRequestOptions(key string, params algoliasearch.Map, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateKeyRes, error) {
+	i.recorder.record(i.name, "updateAPIKey", 0, nil)
+	return algoliasearch.UpdateKeyRes{}, nil
+}
+
+func (i *dryRunIndex) DeleteUserKey(key string) (algoliasearch.DeleteRes, error) {
+	return i.DeleteAPIKeyWithRequestOptions(key, nil)
+}
+
+func (i *dryRunIndex) DeleteAPIKey(key string) (algoliasearch.DeleteRes, error) {
+	return i.DeleteAPIKeyWithRequestOptions(key, nil)
+}
+
+func (i *dryRunIndex) DeleteAPIKeyWithRequestOptions(key string, opts *algoliasearch.RequestOptions) (algoliasearch.DeleteRes, error) {
+	i.recorder.record(i.name, "deleteAPIKey", 0, nil)
+	return algoliasearch.DeleteRes{}, nil
+}
+
+func (i *dryRunIndex) AddObject(object algoliasearch.Object) (algoliasearch.CreateObjectRes, error) {
+	return i.AddObjectWithRequestOptions(object, nil)
+}
+
+func (i *dryRunIndex) AddObjectWithRequestOptions(object algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.CreateObjectRes, error) {
+	i.recorder.record(i.name, "addObject", 1, object)
+	return algoliasearch.CreateObjectRes{}, nil
+}
+
+func (i *dryRunIndex) UpdateObject(object algoliasearch.Object) (algoliasearch.UpdateObjectRes, error) {
+	return i.UpdateObjectWithRequestOptions(object, nil)
+}
+
+func (i *dryRunIndex) UpdateObjectWithRequestOptions(object algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateObjectRes, error) {
+	i.recorder.record(i.name, "updateObject", 1, object)
+	return algoliasearch.UpdateObjectRes{}, nil
+}
+
+func (i *dryRunIndex) PartialUpdateObject(object algoliasearch.Object) (algoliasearch.UpdateTaskRes, error) {
+	return i.PartialUpdateObjectWithRequestOptions(object, nil)
+}
+
+func (i *dryRunIndex) PartialUpdateObjectWithRequestOptions(object algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "partialUpdateObject", 1, object)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) PartialUpdateObjectNoCreate(object algoliasearch.Object) (algoliasearch.UpdateTaskRes, error) {
+	return i.PartialUpdateObjectNoCreateWithRequestOptions(object, nil)
+}
+
+func (i *dryRunIndex) PartialUpdateObjectNoCreateWithRequestOptions(object algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "partialUpdateObjectNoCreate", 1, object)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) DeleteObject(objectID string) (algoliasearch.DeleteTaskRes, error) {
+	return i.DeleteObjectWithRequestOptions(objectID, nil)
+}
+
+func (i *dryRunIndex) DeleteObjectWithRequestOptions(objectID string, opts *algoliasearch.RequestOptions) (algoliasearch.DeleteTaskRes, error) {
+	i.recorder.record(i.name, "deleteObject", 1, nil)
+	return algoliasearch.DeleteTaskRes{}, nil
+}
+
+func (i *dryRunIndex) AddObjects(objects []algoliasearch.Object) (algoliasearch.BatchRes, error) {
+	return i.AddObjectsWithRequestOptions(objects, nil)
+}
+
+func (i *dryRunIndex) AddObjectsWithRequestOptions(objects []algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.BatchRes, error) {
+	i.recorder.record(i.name, "addObjects", len(objects), objects)
+	return algoliasearch.BatchRes{}, nil
+}
+
+func (i *dryRunIndex) UpdateObjects(objects []algoliasearch.Object) (algoliasearch.BatchRes, error) {
+	return i.UpdateObjectsWithRequestOptions(objects, nil)
+}
+
+func (i *dryRunIndex) UpdateObjectsWithRequestOptions(objects []algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.BatchRes, error) {
+	i.recorder.record(i.name, "updateObjects", len(objects), objects)
+	return algoliasearch.BatchRes{}, nil
+}
+
+func (i *dryRunIndex) PartialUpdateObjects(objects []algoliasearch.Object) (algoliasearch.BatchRes, error) {
+	return i.PartialUpdateObjectsWithRequestOptions(objects, nil)
+}
+
+func (i *dryRunIndex) PartialUpdateObjectsWithRequestOptions(objects []algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.BatchRes, error) {
+	i.recorder.record(i.name, "partialUpdateObjects", len(objects), objects)
+	return algoliasearch.BatchRes{}, nil
+}
+
+func (i *dryRunIndex) PartialUpdateObjectsNoCreate(objects []algoliasearch.Object) (algoliasearch.BatchRes, error) {
+	return i.PartialUpdateObjectsNoCreateWithRequestOptions(objects, nil)
+}
+
+func (i *dryRunIndex) PartialUpdateObjectsNoCreateWithRequestOptions(objects []algoliasearch.Object, opts *algoliasearch.RequestOptions) (algoliasearch.BatchRes, error) {
+	i.recorder.record(i.name, "partialUpdateObjectsNoCreate", len(objects), objects)
+	return algoliasearch.BatchRes{}, nil
+}
+
+func (i *dryRunIndex) DeleteObjects(objectIDs []string) (algoliasearch.BatchRes, error) {
+	return i.DeleteObjectsWithRequestOptions(objectIDs, nil)
+}
+
+func (i *dryRunIndex) DeleteObjectsWithRequestOptions(objectIDs []string, opts *algoliasearch.RequestOptions) (algoliasearch.BatchRes, error) {
+	i.recorder.record(i.name, "deleteObjects", len(objectIDs), objectIDs)
+	return algoliasearch.BatchRes{}, nil
+}
+
+func (i *dryRunIndex) DeleteBy(params algoliasearch.Map) (algoliasearch.DeleteTaskRes, error) {
+	return i.DeleteByWithRequestOptions(params, nil)
+}
+
+func (i *dryRunIndex) DeleteByWithRequestOptions(params algoliasearch.Map, opts *algoliasearch.RequestOptions) (algoliasearch.DeleteTaskRes, error) {
+	i.recorder.record(i.name, "deleteBy", 0, params)
+	return algoliasearch.DeleteTaskRes{}, nil
+}
+
+func (i *dryRunIndex) DeleteByQuery(query string, params algoliasearch.Map) error {
+	return i.DeleteByQueryWithRequestOptions(query, params, nil)
+}
+
+// DeleteByQueryWithRequestOptions records the query only: the wrapped index
+// would browse the matching records and delete them through its own methods
+func (i *dryRunIndex) DeleteByQueryWithRequestOptions(query string, params algoliasearch.Map, opts *algoliasearch.RequestOptions) error {
+	i.recorder.record(i.name, "deleteByQuery "+query, 0, params)
+	return nil
+}
+
+func (i *dryRunIndex) Batch(operations []algoliasearch.BatchOperation) (algoliasearch.BatchRes, error) {
+	return i.BatchWithRequestOptions(operations, nil)
+}
+
+func (i *dryRunIndex) BatchWithRequestOptions(operations []algoliasearch.BatchOperation, opts *algoliasearch.RequestOptions) (algoliasearch.BatchRes, error) {
+	counts := map[string]int{}
+	for _, op := range operations {
+		counts[op.Action]++
+	}
+	for action, count := range counts {
+		log.WithField("count", count).Debugf("[dry run] batch %s", action)
+	}
+
+	i.recorder.record(i.name, "batch", len(operations), operations)
+	return algoliasearch.BatchRes{}, nil
+}
+
+func (i *dryRunIndex) AddSynonym(synonym algoliasearch.Synonym, forwardToReplicas bool) (algoliasearch.UpdateTaskRes, error) {
+	return i.AddSynonymWithRequestOptions(synonym, forwardToReplicas, nil)
+}
+
+func (i *dryRunIndex) AddSynonymWithRequestOptions(synonym algoliasearch.Synonym, forwardToReplicas bool, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "addSynonym", 1, synonym)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) DeleteSynonym(objectID string, forwardToReplicas bool) (algoliasearch.DeleteTaskRes, error) {
+	return i.DeleteSynonymWithRequestOptions(objectID, forwardToReplicas, nil)
+}
+
+func (i *dryRunIndex) DeleteSynonymWithRequestOptions(objectID string, forwardToReplicas bool, opts *algoliasearch.RequestOptions) (algoliasearch.DeleteTaskRes, error) {
+	i.recorder.record(i.name, "deleteSynonym", 1, nil)
+	return algoliasearch.DeleteTaskRes{}, nil
+}
+
+func (i *dryRunIndex) ClearSynonyms(forwardToReplicas bool) (algoliasearch.UpdateTaskRes, error) {
+	return i.ClearSynonymsWithRequestOptions(forwardToReplicas, nil)
+}
+
+func (i *dryRunIndex) ClearSynonymsWithRequestOptions(forwardToReplicas bool, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "clearSynonyms", 0, nil)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) BatchSynonyms(synonyms []algoliasearch.Synonym, replaceExistingSynonyms, forwardToReplicas bool) (algoliasearch.UpdateTaskRes, error) {
+	return i.BatchSynonymsWithRequestOptions(synonyms, replaceExistingSynonyms, forwardToReplicas, nil)
+}
+
+func (i *dryRunIndex) BatchSynonymsWithRequestOptions(synonyms []algoliasearch.Synonym, replaceExistingSynonyms, forwardToReplicas bool, opts *algoliasearch.RequestOptions) (algoliasearch.UpdateTaskRes, error) {
+	i.recorder.record(i.name, "batchSynonyms", len(synonyms), synonyms)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *dryRunIndex) SaveRule(rule algoliasearch.Rule, forwardToReplicas bool) (algoliasearch.SaveRuleRes, error) {
+	return i.SaveRuleWithRequestOptions(rule, forwardToReplicas, nil)
+}
+
+func (i *dryRunIndex) SaveRuleWithRequestOptions(rule algoliasearch.Rule, forwardToReplicas bool, opts *algoliasearch.RequestOptions) (algoliasearch.SaveRuleRes, error) {
+	i.recorder.record(i.name, "saveRule", 1, rule)
+	return algoliasearch.SaveRuleRes{}, nil
+}
+
+func (i *dryRunIndex) BatchRules(rules []algoliasearch.Rule, forwardToReplicas, clearExistingRules bool) (algoliasearch.BatchRulesRes, error) {
+	return i.BatchRulesWithRequestOptions(rules, forwardToReplicas, clearExistingRules, nil)
+}
+
+func (i *dryRunIndex) BatchRulesWithRequestOptions(rules []algoliasearch.Rule, forwardToReplicas, clearExistingRules bool, opts *algoliasearch.RequestOptions) (algoliasearch.BatchRulesRes, error) {
+	i.recorder.record(i.name, "batchRules", len(rules), rules)
+	return algoliasearch.BatchRulesRes{}, nil
+}
+
+func (i *dryRunIndex) DeleteRule(objectID string, forwardToReplicas bool) (algoliasearch.DeleteRuleRes, error) {
+	return i.DeleteRuleWithRequestOptions(objectID, forwardToReplicas, nil)
+}
+
+func (i *dryRunIndex) DeleteRuleWithRequestOptions(objectID string, forwardToReplicas bool, opts *algoliasearch.RequestOptions) (algoliasearch.DeleteRuleRes, error) {
+	i.recorder.record(i.name, "deleteRule", 1, nil)
+	return algoliasearch.DeleteRuleRes{}, nil
+}
+
+func (i *dryRunIndex) ClearRules(forwardToReplicas bool) (algoliasearch.ClearRulesRes, error) {
+	return i.ClearRulesWithRequestOptions(forwardToReplicas, nil)
+}
+
+func (i *dryRunIndex) ClearRulesWithRequestOptions(forwardToReplicas bool, opts *algoliasearch.RequestOptions) (algoliasearch.ClearRulesRes, error) {
+	i.recorder.record(i.name, "clearRules", 0, nil)
+	return algoliasearch.ClearRulesRes{}, nil
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// callAll calls every method of v, an interface value of type iface, whose
+// name has none of the read-only prefixes, with zero arguments. The methods
+// of the wrapped client and index panic, since they are nil, so a call the
+// dry run does not override is reported instead of being sent.
+func callAll(t *testing.T, iface reflect.Type, v interface{}, readOnly []string) int {
+	value := reflect.ValueOf(v)
+	called := 0
+methods:
+	for m := 0; m < iface.NumMethod(); m++ {
+		method := iface.Method(m)
+		for _, prefix := range readOnly {
+			if strings.HasPrefix(method.Name, prefix) {
+				continue methods
+			}
+		}
+
+		args := make([]reflect.Value, method.Type.NumIn())
+		for a := range args {
+			args[a] = reflect.Zero(method.Type.In(a))
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s %s reached the wrapped client: %v", iface.Name(), method.Name, r)
+				}
+			}()
+			value.MethodByName(method.Name).Call(args)
+			called++
+		}()
+	}
+	return called
+}
+
+func TestDryRunMakesNoMutatingCalls(t *testing.T) {
+	recorder := &DryRunRecorder{}
+
+	clientType := reflect.TypeOf((*algoliasearch.Client)(nil)).Elem()
+	client := NewDryRunClient(struct{ algoliasearch.Client }{}, recorder)
+	clientCalls := callAll(t, clientType, client, []string{"Set", "List", "Get", "MultipleQueries", "InitIndex"})
+
+	indexType := reflect.TypeOf((*algoliasearch.Index)(nil)).Elem()
+	index := NewDryRunIndex(struct{ algoliasearch.Index }{}, "docs", recorder)
+	indexCalls := callAll(t, indexType, index, []string{"Get", "List", "Search", "Browse"})
+
+	// Waiting for a task sends nothing, so it records nothing either
+	want := clientCalls + indexCalls - 2
+	if len(recorder.Operations) != want {
+		t.Errorf("recorded %d operations, want %d", len(recorder.Operations), want)
+	}
+	for _, op := range recorder.Operations {
+		if op.Action == "" {
+			t.Errorf("operation %+v has no action", op)
+		}
+	}
+}
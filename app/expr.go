@@ -0,0 +1,1021 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/spf13/cast"
+)
+
+// Expression is a compiled expression evaluated against a record. The
+// language only reads the record and calls the built-in functions, so
+// expressions from a configuration file cannot reach anything else.
+type Expression struct {
+	source string
+	root   node
+}
+
+// CompileExpression parses an expression
+func CompileExpression(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against a record, with now as the current
+// time. Dates in the result are converted to Unix timestamps and durations to
+// seconds, so the result can be stored in a record like any decoded JSON value.
+func (e *Expression) Eval(object algoliasearch.Object, now time.Time) (interface{}, error) {
+	v, err := e.root.eval(&exprEnv{object: object, now: now})
+	if err != nil {
+		return nil, err
+	}
+	v = recordValue(v)
+	// NaN and infinities cannot be encoded as JSON
+	if !finite(v) {
+		return nil, fmt.Errorf("the result is not a finite number")
+	}
+	return v, nil
+}
+
+// exprEnv is what an expression can see while it is evaluated
+type exprEnv struct {
+	object algoliasearch.Object
+	now    time.Time
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokDuration
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value interface{}
+}
+
+// durationUnits are the suffixes allowed on duration literals such as 90d
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+var twoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+const oneCharOps = "+-*/%<>!()[],.?:|"
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// An exponent, as in 1e6 or 2.5E-3
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					for i = j; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+					}
+				}
+			}
+			text := string(runes[start:i])
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("position %d: invalid number %q", start+1, text)
+			}
+
+			unitStart := i
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				i++
+			}
+			if unit := string(runes[unitStart:i]); unit != "" {
+				d, ok := durationUnits[unit]
+				if !ok {
+					return nil, fmt.Errorf("position %d: unknown duration unit %q", unitStart+1, unit)
+				}
+				if n*float64(d) >= math.MaxInt64 {
+					return nil, fmt.Errorf("position %d: duration %s is out of range", start+1, string(runes[start:i]))
+				}
+				tokens = append(tokens, token{kind: tokDuration, text: string(runes[start:i]), pos: start, value: time.Duration(n * float64(d))})
+			} else {
+				tokens = append(tokens, token{kind: tokNumber, text: text, pos: start, value: n})
+			}
+
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						b.WriteRune('\n')
+					case 't':
+						b.WriteRune('\t')
+					default:
+						b.WriteRune(runes[i])
+					}
+					continue
+				}
+				b.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("position %d: unterminated string", start+1)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: string(runes[start:i]), pos: start, value: b.String()})
+
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			op := ""
+			if i+1 < len(runes) {
+				for _, two := range twoCharOps {
+					if string(runes[i:i+2]) == two {
+						op = two
+					}
+				}
+			}
+			if op == "" && strings.ContainsRune(oneCharOps, r) {
+				op = string(r)
+			}
+			if op == "" {
+				return nil, fmt.Errorf("position %d: unexpected %q", start+1, string(r))
+			}
+			i += len([]rune(op))
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "end of expression", pos: len(runes)}), nil
+}
+
+// Parser
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the operator or keyword text
+func (p *parser) accept(text string) bool {
+	tok := p.peek()
+	if (tok.kind == tokOp || tok.kind == tokIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("position %d: %s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+// parsePipe parses x | f(args), which calls f with x as its first argument
+func (p *parser) parsePipe() (node, error) {
+	x, err := p.parseCond()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("|") {
+		tok := p.next()
+		if tok.kind != tokIdent {
+			return nil, p.errorf(tok, "expected a function after |, found %q", tok.text)
+		}
+		args := []node{x}
+		if p.accept("(") {
+			var more []node
+			if more, err = p.parseList(")"); err != nil {
+				return nil, err
+			}
+			args = append(args, more...)
+		}
+		if x, err = p.newCall(tok, args); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+func (p *parser) parseCond() (node, error) {
+	c, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return c, nil
+	}
+
+	a, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{cond: c, then: a, otherwise: b}, nil
+}
+
+// binaryLevels lists the binary operators from the lowest to the highest precedence
+var binaryLevels = [][]string{
+	{"||", "or"},
+	{"&&", "and"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range binaryLevels[level] {
+			if p.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return x, nil
+		}
+
+		var y node
+		if y, err = p.parseBinary(level + 1); err != nil {
+			return nil, err
+		}
+		x = &binaryNode{op: op, x: x, y: y}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	for _, op := range []string{"!", "not", "-"} {
+		if p.accept(op) {
+			x, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return &unaryNode{op: op, x: x}, nil
+		}
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.accept("."):
+			tok := p.next()
+			if tok.kind != tokIdent {
+				return nil, p.errorf(tok, "expected an attribute name after ., found %q", tok.text)
+			}
+			x = &indexNode{x: x, index: &literalNode{value: tok.text}}
+		case p.accept("["):
+			var index node
+			if index, err = p.parsePipe(); err != nil {
+				return nil, err
+			}
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &indexNode{x: x, index: index}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber, tokDuration, tokString:
+		return &literalNode{value: tok.value}, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		case "and", "or", "not", "in":
+			return nil, p.errorf(tok, "unexpected %q", tok.text)
+		}
+		if p.accept("(") {
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return p.newCall(tok, args)
+		}
+		return &identNode{name: tok.text}, nil
+
+	case tokOp:
+		switch tok.text {
+		case "(":
+			x, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+// parseList parses comma separated expressions up to the closing token
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if p.accept(closing) {
+		return items, nil
+	}
+	for {
+		item, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.accept(closing) {
+			return items, nil
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) newCall(tok token, args []node) (node, error) {
+	f, ok := builtins[tok.text]
+	if !ok {
+		return nil, p.errorf(tok, "unknown function %q", tok.text)
+	}
+	if len(args) < f.minArgs || (f.maxArgs >= 0 && len(args) > f.maxArgs) {
+		return nil, p.errorf(tok, "wrong number of arguments to %s", tok.text)
+	}
+	return &callNode{name: tok.text, fn: f, args: args}, nil
+}
+
+// Evaluation
+
+type node interface {
+	eval(env *exprEnv) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(env *exprEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+// identNode reads a record attribute, or the current time for now
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(env *exprEnv) (interface{}, error) {
+	if n.name == "now" {
+		return env.now, nil
+	}
+	return exprValue(env.object[n.name]), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(env *exprEnv) (interface{}, error) {
+	list := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+// indexNode reads an attribute of an object or an element of a list
+type indexNode struct {
+	x, index node
+}
+
+func (n *indexNode) eval(env *exprEnv) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x := x.(type) {
+	case map[string]interface{}:
+		return exprValue(x[toString(index)]), nil
+	case []interface{}:
+		var i int
+		if i, err = toInt(index); err != nil {
+			return nil, fmt.Errorf("index: %s", err)
+		}
+		if i < 0 {
+			i += len(x)
+		}
+		if i < 0 || i >= len(x) {
+			return nil, nil
+		}
+		return x[i], nil
+	}
+	return nil, nil
+}
+
+type condNode struct {
+	cond, then, otherwise node
+}
+
+func (n *condNode) eval(env *exprEnv) (interface{}, error) {
+	c, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(c) {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) eval(env *exprEnv) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op != "-" {
+		return !truthy(x), nil
+	}
+
+	switch x := x.(type) {
+	case nil:
+		return nil, nil
+	case time.Duration:
+		return -x, nil
+	}
+	f, err := toNumber(x)
+	return -f, err
+}
+
+type callNode struct {
+	name string
+	fn   builtin
+	args []node
+}
+
+func (n *callNode) eval(env *exprEnv) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	// Most functions pass null through, so missing attributes stay missing
+	if !n.fn.acceptsNull && len(args) > 0 && args[0] == nil {
+		return nil, nil
+	}
+
+	v, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", n.name, err)
+	}
+	return v, nil
+}
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+func (n *binaryNode) eval(env *exprEnv) (interface{}, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// and and or only evaluate their right side when they need to
+	switch n.op {
+	case "&&", "and":
+		if !truthy(x) {
+			return false, nil
+		}
+		y, yerr := n.y.eval(env)
+		return truthy(y), yerr
+	case "||", "or":
+		if truthy(x) {
+			return true, nil
+		}
+		y, yerr := n.y.eval(env)
+		return truthy(y), yerr
+	}
+
+	y, err := n.y.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "in":
+		return contains(y, x), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, x, y)
+	}
+	return arithmetic(n.op, x, y)
+}
+
+// arithmetic applies + - * / % to numbers, and to dates and durations where it
+// makes sense. + also joins strings and lists. null gives null.
+func arithmetic(op string, x, y interface{}) (interface{}, error) {
+	if x == nil || y == nil {
+		return nil, nil
+	}
+
+	_, xTime := x.(time.Time)
+	_, yTime := y.(time.Time)
+	xDur, xIsDur := x.(time.Duration)
+	yDur, yIsDur := y.(time.Duration)
+
+	switch {
+	case xTime || yTime || (xIsDur && yIsDur) || ((xIsDur || yIsDur) && (isString(x) || isString(y))):
+		return dateArithmetic(op, x, y)
+
+	case xIsDur || yIsDur:
+		// A duration can be scaled by a number
+		switch {
+		case xIsDur && op == "*":
+			f, err := toNumber(y)
+			return time.Duration(float64(xDur) * f), err
+		case yIsDur && op == "*":
+			f, err := toNumber(x)
+			return time.Duration(float64(yDur) * f), err
+		case xIsDur && op == "/":
+			f, err := toNumber(y)
+			if err == nil && f == 0 {
+				err = fmt.Errorf("division by zero")
+			}
+			return time.Duration(float64(xDur) / f), err
+		}
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", op, describeValue(x), describeValue(y))
+	}
+
+	if op == "+" {
+		xs, xList := x.([]interface{})
+		ys, yList := y.([]interface{})
+		switch {
+		case xList && yList:
+			return append(append([]interface{}{}, xs...), ys...), nil
+		case isString(x) || isString(y):
+			return toString(x) + toString(y), nil
+		}
+	}
+
+	a, err := toNumber(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toNumber(y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	default:
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+}
+
+// dateArithmetic handles date ± duration, date - date and duration ± duration.
+// Strings next to a date or duration are parsed as dates.
+func dateArithmetic(op string, x, y interface{}) (interface{}, error) {
+	if xDur, ok := x.(time.Duration); ok {
+		if yDur, ok := y.(time.Duration); ok {
+			switch op {
+			case "+":
+				return xDur + yDur, nil
+			case "-":
+				return xDur - yDur, nil
+			case "/":
+				if yDur == 0 {
+					return nil, fmt.Errorf("division by zero")
+				}
+				return float64(xDur) / float64(yDur), nil
+			}
+		} else if op == "+" {
+			t, err := toTime(y)
+			return t.Add(xDur), err
+		}
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", op, describeValue(x), describeValue(y))
+	}
+
+	t, err := toTime(x)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := y.(time.Duration); ok {
+		switch op {
+		case "+":
+			return t.Add(d), nil
+		case "-":
+			return t.Add(-d), nil
+		}
+	} else if op == "-" {
+		u, uerr := toTime(y)
+		return t.Sub(u), uerr
+	}
+	return nil, fmt.Errorf("cannot apply %s to %s and %s", op, describeValue(x), describeValue(y))
+}
+
+// compare orders two numbers, strings, dates or durations. null gives null.
+func compare(op string, x, y interface{}) (interface{}, error) {
+	if x == nil || y == nil {
+		return nil, nil
+	}
+
+	var c int
+	_, xTime := x.(time.Time)
+	_, yTime := y.(time.Time)
+	switch {
+	case xTime || yTime:
+		a, err := toTime(x)
+		if err != nil {
+			return nil, err
+		}
+		b, err := toTime(y)
+		if err != nil {
+			return nil, err
+		}
+		c = compareFloats(float64(a.UnixNano()), float64(b.UnixNano()))
+
+	case isString(x) && isString(y):
+		c = strings.Compare(x.(string), y.(string))
+
+	default:
+		a, err := toNumber(x)
+		if err != nil {
+			return nil, err
+		}
+		b, err := toNumber(y)
+		if err != nil {
+			return nil, err
+		}
+		c = compareFloats(a, b)
+	}
+
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func equal(x, y interface{}) bool {
+	if a, ok := x.(float64); ok {
+		b, ok := y.(float64)
+		return ok && a == b
+	}
+	if a, ok := x.(time.Time); ok {
+		b, err := toTime(y)
+		return err == nil && a.Equal(b)
+	}
+	if b, ok := y.(time.Time); ok {
+		a, err := toTime(x)
+		return err == nil && a.Equal(b)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// contains reports whether a list holds x, a string contains x or an object has the key x
+func contains(container, x interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, item := range c {
+			if equal(item, x) {
+				return true
+			}
+		}
+	case string:
+		return x != nil && strings.Contains(c, toString(x))
+	case map[string]interface{}:
+		_, ok := c[toString(x)]
+		return ok
+	}
+	return false
+}
+
+// Values
+
+// exprValue converts a record value to the types expressions work with:
+// numbers are float64 and lists are []interface{}
+func exprValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	case algoliasearch.Object:
+		return map[string]interface{}(v)
+	case algoliasearch.Map:
+		return map[string]interface{}(v)
+	}
+	return v
+}
+
+// recordValue converts an expression result to a value that can be stored in a record
+func recordValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case time.Time:
+		return float64(v.Unix())
+	case time.Duration:
+		return v.Seconds()
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = recordValue(item)
+		}
+		return list
+	}
+	return v
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	case time.Time:
+		return !v.IsZero()
+	case time.Duration:
+		return v != 0
+	}
+	return true
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = toString(item)
+		}
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+func toNumber(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f, nil
+		}
+	case time.Time:
+		return float64(v.Unix()), nil
+	case time.Duration:
+		return v.Seconds(), nil
+	}
+	return 0, fmt.Errorf("cannot use %s as a number", describeValue(v))
+}
+
+// toInt reads a whole number small enough to be an index or a length
+func toInt(v interface{}) (int, error) {
+	f, err := toNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%s is not a whole number", describeValue(v))
+	}
+	if f < math.MinInt32 || f > math.MaxInt32 {
+		return 0, fmt.Errorf("%s is out of range", describeValue(v))
+	}
+	return int(f), nil
+}
+
+// finite reports whether a value holds no NaN or infinite number
+func finite(v interface{}) bool {
+	switch v := v.(type) {
+	case float64:
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
+	case []interface{}:
+		for _, item := range v {
+			if !finite(item) {
+				return false
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if !finite(item) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// toTime reads a date from a date, a date string or a Unix timestamp
+func toTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case float64:
+		if i, ok := floatToInt(v); ok {
+			return time.Unix(i, 0).UTC(), nil
+		}
+	case string:
+		if t, err := cast.StringToDate(strings.TrimSpace(v)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot use %s as a date", describeValue(v))
+}
+
+func toList(v interface{}) ([]interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		return v, nil
+	}
+	return nil, fmt.Errorf("cannot use %s as a list", describeValue(v))
+}
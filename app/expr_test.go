@@ -0,0 +1,177 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+var exprNow = time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func evalExpr(t *testing.T, src string, object algoliasearch.Object) (interface{}, error) {
+	t.Helper()
+	expr, err := CompileExpression(src)
+	if err != nil {
+		return nil, err
+	}
+	return expr.Eval(object, exprNow)
+}
+
+func TestExpressionEval(t *testing.T) {
+	object := algoliasearch.Object{
+		"title":     "Hello World",
+		"section":   "blog",
+		"wordCount": float64(450),
+		"tags":      []interface{}{"go", "hugo", "go"},
+		"date":      "2018-05-01T00:00:00Z",
+		"hierarchy": map[string]interface{}{"lvl0": "Docs"},
+		"draft":     false,
+	}
+
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		// Precedence and associativity
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"2 * 3 % 4", 2.0},
+		{"-2 * 3", -6.0},
+		{"1 + 2 == 3", true},
+		{"1 < 2 == true", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"not true or true", true},
+		{"!draft && section == 'blog'", true},
+		{"1 > 2 ? 'a' : 2 > 1 ? 'b' : 'c'", "b"},
+		{"'go' in tags", true},
+		{"'rust' in tags", false},
+		{"title | lower | replace('world', 'there')", "hello there"},
+
+		// Strings and lists
+		{"'a' + 1", "a1"},
+		{"[1, 2] + [3]", []interface{}{1.0, 2.0, 3.0}},
+		{"tags[0]", "go"},
+		{"tags[-1]", "go"},
+		{"tags[5]", nil},
+		{"hierarchy.lvl0", "Docs"},
+		{"hierarchy['lvl0']", "Docs"},
+		{"missing.deeper", nil},
+		{"len(unique(tags))", 2.0},
+		{"join(sort(unique(tags)), ',')", "go,hugo"},
+		{"substr(title, 6)", "World"},
+		{"substr(title, 0, 5)", "Hello"},
+		{"substr(title, 20, 5)", ""},
+		{"substr(title, -3, 100)", "Hello World"},
+		{"coalesce(missing, section)", "blog"},
+
+		// Numbers
+		{"round(wordCount / 200)", 2.0},
+		{"round(3.14159, 2)", 3.14},
+		{"round(1234, -2)", 1200.0},
+		{"round(1e300, 17)", 1e300},
+		{"min(3, 1, 2)", 1.0},
+		{"max(tags | len, 1)", 3.0},
+
+		// Null propagation
+		{"missing + 1", nil},
+		{"missing < 1", nil},
+		{"lower(missing)", nil},
+
+		// Dates and durations
+		{"date < now", true},
+		{"date > now - 30d", false},
+		{"(now - date) / 1d", 31.0},
+		{"year(date)", 2018.0},
+		{"format(date, '2006-01-02')", "2018-05-01"},
+		{"date + 1d", float64(time.Date(2018, 5, 2, 0, 0, 0, 0, time.UTC).Unix())},
+		{"2h", 7200.0},
+		{"1.5e3 + 2E-1", 1500.2},
+	}
+
+	for _, tt := range tests {
+		got, err := evalExpr(t, tt.src, object)
+		if err != nil {
+			t.Errorf("%s: %s", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	object := algoliasearch.Object{
+		"title": "Hello",
+		"tags":  []interface{}{"go"},
+	}
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		// Syntax
+		{"", "unexpected"},
+		{"1 +", "unexpected"},
+		{"(1 + 2", `expected ")"`},
+		{"'unterminated", "unterminated"},
+		{"1 2", "unexpected"},
+		{"nope(1)", "unknown function"},
+		{"lower()", "argument"},
+		{"a ? b", `expected ":"`},
+
+		// Evaluation
+		{"1 / 0", "division by zero"},
+		{"1 % 0", "division by zero"},
+		{"title * 2", "as a number"},
+		{"tags[10000000000000000000000]", "out of range"},
+		{"tags[1.5]", "not a whole number"},
+		{"substr(title, 1e22, 5)", "out of range"},
+		{"substr(title, 0, 1e22)", "out of range"},
+		{"truncate(title, 0.5)", "not a whole number"},
+		{"truncate(title, -1)", "negative length"},
+		{"round(1, 1000)", "out of range"},
+		{"round(1, 0.5)", "not a whole number"},
+		{"number('NaN')", "not a finite number"},
+		{"1e308 * 10", "not a finite number"},
+		{"date(1e300)", "as a date"},
+		{"1e300d", "out of range"},
+	}
+
+	for _, tt := range tests {
+		got, err := evalExpr(t, tt.src, object)
+		if err == nil {
+			t.Errorf("%s = %#v, want an error containing %q", tt.src, got, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q, want it to contain %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestComputedAttributesApply(t *testing.T) {
+	objects := []algoliasearch.Object{
+		{"title": "A", "section": "docs"},
+		{"title": "B"},
+	}
+	computed := ComputedAttributes{
+		{Attribute: "hierarchy.lvl0", Expression: "section | title"},
+		{Attribute: "label", Expression: "hierarchy.lvl0 + ': ' + title"},
+	}
+
+	if err := computed.Apply(objects); err != nil {
+		t.Fatal(err)
+	}
+	if got := objects[0]["label"]; got != "Docs: A" {
+		t.Errorf("label = %#v, want %q", got, "Docs: A")
+	}
+	if _, ok := objects[1]["hierarchy"]; ok {
+		t.Errorf("null result set hierarchy on record 1: %v", objects[1])
+	}
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// fakeClient is an in-memory algoliasearch.Client. It implements the calls
+// the app makes to manage indexes and records every one of them; any other
+// call panics on the nil embedded client.
+type fakeClient struct {
+	algoliasearch.Client
+	// indexes holds the indexes that exist, known every index ever initialized
+	indexes map[string]*fakeIndex
+	known   map[string]*fakeIndex
+	calls   []string
+	// fail makes the calls starting with any of its prefixes return an error
+	fail []string
+	// queries holds the queries sent with MultipleQueries
+	queries []algoliasearch.IndexedQuery
+}
+
+func newFakeClient(indexes ...*fakeIndex) *fakeClient {
+	c := &fakeClient{indexes: map[string]*fakeIndex{}, known: map[string]*fakeIndex{}}
+	for _, i := range indexes {
+		i.client = c
+		c.indexes[i.name] = i
+		c.known[i.name] = i
+	}
+	return c
+}
+
+// call records a call and returns the error it was set up to fail with
+func (c *fakeClient) call(format string, args ...interface{}) error {
+	call := fmt.Sprintf(format, args...)
+	c.calls = append(c.calls, call)
+	for _, prefix := range c.fail {
+		if strings.HasPrefix(call, prefix) {
+			return errors.New(call + " failed")
+		}
+	}
+	return nil
+}
+
+// index returns the index with the given name, which only exists once it is
+// written to
+func (c *fakeClient) index(name string) *fakeIndex {
+	i, ok := c.known[name]
+	if !ok {
+		i = &fakeIndex{name: name, client: c}
+		c.known[name] = i
+	}
+	return i
+}
+
+func (c *fakeClient) ListIndexes() ([]algoliasearch.IndexRes, error) {
+	var res []algoliasearch.IndexRes
+	for name := range c.indexes {
+		res = append(res, algoliasearch.IndexRes{Name: name})
+	}
+	return res, c.call("listIndexes")
+}
+
+func (c *fakeClient) InitIndex(name string) algoliasearch.Index {
+	return c.index(name)
+}
+
+func (c *fakeClient) MoveIndex(source, destination string) (algoliasearch.UpdateTaskRes, error) {
+	if err := c.call("move %s to %s", source, destination); err != nil {
+		return algoliasearch.UpdateTaskRes{}, err
+	}
+	i := c.index(source)
+	delete(c.indexes, source)
+	delete(c.known, source)
+	i.name = destination
+	c.indexes[destination] = i
+	c.known[destination] = i
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (c *fakeClient) ScopedCopyIndex(source, destination string, scopes []string) (algoliasearch.UpdateTaskRes, error) {
+	if err := c.call("copy %s to %s", source, destination); err != nil {
+		return algoliasearch.UpdateTaskRes{}, err
+	}
+	dst := c.index(destination)
+	dst.create()
+	dst.settings = algoliasearch.Map{}
+	for k, v := range c.index(source).settings {
+		dst.settings[k] = v
+	}
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (c *fakeClient) MultipleQueries(queries []algoliasearch.IndexedQuery, strategy string) ([]algoliasearch.MultipleQueryRes, error) {
+	if err := c.call("multipleQueries %d", len(queries)); err != nil {
+		return nil, err
+	}
+	c.queries = append(c.queries, queries...)
+	res := make([]algoliasearch.MultipleQueryRes, len(queries))
+	for n, q := range queries {
+		query, _ := q.Params["query"].(string)
+		res[n] = algoliasearch.MultipleQueryRes{Index: q.IndexName, QueryRes: c.index(q.IndexName).results[query]}
+	}
+	return res, nil
+}
+
+func (c *fakeClient) DeleteIndex(name string) (algoliasearch.DeleteTaskRes, error) {
+	delete(c.indexes, name)
+	delete(c.known, name)
+	return algoliasearch.DeleteTaskRes{}, c.call("delete %s", name)
+}
+
+// fakeIndex is an in-memory algoliasearch.Index holding settings and records
+type fakeIndex struct {
+	algoliasearch.Index
+	name     string
+	client   *fakeClient
+	settings algoliasearch.Map
+	objects  []algoliasearch.Object
+	synonyms []algoliasearch.Synonym
+	rules    []algoliasearch.Rule
+	// results holds the results of MultipleQueries by query
+	results map[string]algoliasearch.QueryRes
+}
+
+// create adds the index to its client the first time it is written to
+func (i *fakeIndex) create() {
+	if _, ok := i.client.indexes[i.name]; !ok {
+		i.client.indexes[i.name] = i
+	}
+}
+
+func (i *fakeIndex) GetSettings() (algoliasearch.Settings, error) {
+	if err := i.client.call("getSettings %s", i.name); err != nil {
+		return algoliasearch.Settings{}, err
+	}
+	ranking, _ := i.settings["customRanking"].([]string)
+	replicas, _ := i.settings["replicas"].([]string)
+	return algoliasearch.Settings{CustomRanking: ranking, Replicas: replicas}, nil
+}
+
+func (i *fakeIndex) SetSettings(settings algoliasearch.Map) (algoliasearch.UpdateTaskRes, error) {
+	if err := i.client.call("setSettings %s", i.name); err != nil {
+		return algoliasearch.UpdateTaskRes{}, err
+	}
+	i.create()
+	if i.settings == nil {
+		i.settings = algoliasearch.Map{}
+	}
+	for k, v := range settings {
+		i.settings[k] = v
+	}
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *fakeIndex) AddObjects(objects []algoliasearch.Object) (algoliasearch.BatchRes, error) {
+	if err := i.client.call("addObjects %s", i.name); err != nil {
+		return algoliasearch.BatchRes{}, err
+	}
+	i.create()
+	i.objects = append(i.objects, objects...)
+	return algoliasearch.BatchRes{}, nil
+}
+
+func (i *fakeIndex) WaitTask(taskID int) error {
+	return nil
+}
+
+func (i *fakeIndex) SearchSynonyms(query string, types []string, page, hitsPerPage int) ([]algoliasearch.Synonym, error) {
+	if err := i.client.call("searchSynonyms %s %d", i.name, page); err != nil {
+		return nil, err
+	}
+	start, end := page*hitsPerPage, (page+1)*hitsPerPage
+	if start > len(i.synonyms) {
+		start = len(i.synonyms)
+	}
+	if end > len(i.synonyms) {
+		end = len(i.synonyms)
+	}
+	return append([]algoliasearch.Synonym{}, i.synonyms[start:end]...), nil
+}
+
+func (i *fakeIndex) BatchSynonyms(synonyms []algoliasearch.Synonym, replaceExistingSynonyms, forwardToReplicas bool) (algoliasearch.UpdateTaskRes, error) {
+	if err := i.client.call("batchSynonyms %s replace=%t forward=%t", i.name, replaceExistingSynonyms, forwardToReplicas); err != nil {
+		return algoliasearch.UpdateTaskRes{}, err
+	}
+	if replaceExistingSynonyms {
+		i.synonyms = nil
+	}
+	i.synonyms = append(i.synonyms, synonyms...)
+	return algoliasearch.UpdateTaskRes{}, nil
+}
+
+func (i *fakeIndex) DeleteSynonym(objectID string, forwardToReplicas bool) (algoliasearch.DeleteTaskRes, error) {
+	if err := i.client.call("deleteSynonym %s %s forward=%t", i.name, objectID, forwardToReplicas); err != nil {
+		return algoliasearch.DeleteTaskRes{}, err
+	}
+	for n, s := range i.synonyms {
+		if s.ObjectID == objectID {
+			i.synonyms = append(i.synonyms[:n], i.synonyms[n+1:]...)
+			break
+		}
+	}
+	return algoliasearch.DeleteTaskRes{}, nil
+}
+
+func (i *fakeIndex) SearchRules(params algoliasearch.Map) (algoliasearch.SearchRulesRes, error) {
+	page, perPage := params["page"].(int), params["hitsPerPage"].(int)
+	if err := i.client.call("searchRules %s %d", i.name, page); err != nil {
+		return algoliasearch.SearchRulesRes{}, err
+	}
+	res := algoliasearch.SearchRulesRes{Page: page, NbHits: len(i.rules), NbPages: (len(i.rules) + perPage - 1) / perPage}
+	for n := page * perPage; n < len(i.rules) && n < (page+1)*perPage; n++ {
+		res.Hits = append(res.Hits, i.rules[n])
+	}
+	return res, nil
+}
+
+func (i *fakeIndex) BatchRules(rules []algoliasearch.Rule, forwardToReplicas, clearExistingRules bool) (algoliasearch.BatchRulesRes, error) {
+	if err := i.client.call("batchRules %s forward=%t clear=%t", i.name, forwardToReplicas, clearExistingRules); err != nil {
+		return algoliasearch.BatchRulesRes{}, err
+	}
+	i.create()
+	if clearExistingRules {
+		i.rules = nil
+	}
+	i.rules = append(i.rules, rules...)
+	return algoliasearch.BatchRulesRes{}, nil
+}
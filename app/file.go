@@ -0,0 +1,82 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// isYAML reports whether the file should be read and written as YAML rather than JSON
+func isYAML(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// ReadDataFile decodes a YAML or JSON file, chosen by its extension, into v.
+// YAML is converted to JSON first so the json struct tags apply to both formats.
+func ReadDataFile(file string, v interface{}) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if isYAML(file) {
+		var raw interface{}
+		if err = yaml.Unmarshal(b, &raw); err != nil {
+			return err
+		}
+		if b, err = json.Marshal(jsonValue(raw)); err != nil {
+			return err
+		}
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// WriteDataFile encodes v as YAML or JSON, chosen by the file extension
+func WriteDataFile(file string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if isYAML(file) {
+		var raw interface{}
+		if err = json.Unmarshal(b, &raw); err != nil {
+			return err
+		}
+		if b, err = yaml.Marshal(raw); err != nil {
+			return err
+		}
+	} else {
+		b = append(b, '\n')
+	}
+
+	return ioutil.WriteFile(file, b, 0644)
+}
+
+// jsonValue converts the map[interface{}]interface{} values produced by the
+// YAML decoder into map[string]interface{} so they can be encoded as JSON
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = jsonValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = jsonValue(val)
+		}
+		return v
+	}
+	return v
+}
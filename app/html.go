@@ -0,0 +1,545 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// htmlNode is an element or a run of text in a parsed HTML document
+type htmlNode struct {
+	// tag is the lower case element name, or empty for text
+	tag      string
+	attrs    map[string]string
+	text     string
+	parent   *htmlNode
+	children []*htmlNode
+}
+
+var (
+	voidElements = setOf("area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track", "wbr")
+	rawElements  = setOf("script", "style", "textarea", "title")
+	// blockElements close an open paragraph and separate the words around them
+	blockElements = setOf("address", "article", "aside", "blockquote", "dd", "details", "div", "dl", "dt", "fieldset",
+		"figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hr", "li", "main",
+		"nav", "ol", "p", "pre", "section", "table", "tbody", "td", "tfoot", "th", "thead", "tr", "ul")
+	// implicitlyClosed lists, for an element, the open elements that it closes
+	implicitlyClosed = map[string]map[string]bool{
+		"li":     setOf("li"),
+		"dt":     setOf("dt", "dd"),
+		"dd":     setOf("dt", "dd"),
+		"tr":     setOf("tr", "td", "th"),
+		"td":     setOf("td", "th"),
+		"th":     setOf("td", "th"),
+		"option": setOf("option"),
+	}
+)
+
+func setOf(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// parseHTML builds a tree from an HTML document. Like a browser it never
+// fails: stray end tags are ignored and unclosed elements are closed at the
+// end of their parent.
+func parseHTML(s string) *htmlNode {
+	root := &htmlNode{tag: "#document"}
+	open := []*htmlNode{root}
+	current := func() *htmlNode { return open[len(open)-1] }
+	appendChild := func(n *htmlNode) {
+		n.parent = current()
+		n.parent.children = append(n.parent.children, n)
+	}
+	closeTo := func(i int) { open = open[:i] }
+
+	for len(s) > 0 {
+		lt := strings.IndexByte(s, '<')
+		if lt < 0 {
+			lt = len(s)
+		}
+		if lt > 0 {
+			appendChild(&htmlNode{text: html.UnescapeString(s[:lt])})
+			s = s[lt:]
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				return root
+			}
+			s = s[end+3:]
+
+		case strings.HasPrefix(s, "<!") || strings.HasPrefix(s, "<?"):
+			s = skipPast(s, '>')
+
+		case strings.HasPrefix(s, "</"):
+			name := tagName(s[2:])
+			s = skipPast(s, '>')
+			for i := len(open) - 1; i > 0; i-- {
+				if open[i].tag == name {
+					closeTo(i)
+					break
+				}
+			}
+
+		case len(s) > 1 && isTagStart(s[1]):
+			var n *htmlNode
+			var selfClosing bool
+			n, selfClosing, s = parseStartTag(s)
+
+			if blockElements[n.tag] && current().tag == "p" {
+				closeTo(len(open) - 1)
+			}
+			// A new row closes both the open cell and the row it is in
+			for len(open) > 1 && implicitlyClosed[n.tag][current().tag] {
+				closeTo(len(open) - 1)
+			}
+
+			appendChild(n)
+			if rawElements[n.tag] {
+				end := indexFold(s, "</"+n.tag)
+				if end < 0 {
+					end = len(s)
+				}
+				n.children = []*htmlNode{{text: s[:end], parent: n}}
+				s = skipPast(s[end:], '>')
+			} else if !selfClosing && !voidElements[n.tag] {
+				open = append(open, n)
+			}
+
+		default:
+			// A lone < is text
+			appendChild(&htmlNode{text: "<"})
+			s = s[1:]
+		}
+	}
+	return root
+}
+
+func isTagStart(c byte) bool {
+	return c < 0x80 && unicode.IsLetter(rune(c))
+}
+
+// tagName reads the lower case tag name at the start of s
+func tagName(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '>' || r == '/'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	return strings.ToLower(s[:end])
+}
+
+// skipPast returns what follows the first c in s, or nothing
+func skipPast(s string, c byte) string {
+	if i := strings.IndexByte(s, c); i >= 0 {
+		return s[i+1:]
+	}
+	return ""
+}
+
+// indexFold is strings.Index, ignoring ASCII case
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// parseStartTag reads a start tag and its attributes, returning the element,
+// whether it closed itself and the rest of the document
+func parseStartTag(s string) (*htmlNode, bool, string) {
+	name := tagName(s[1:])
+	n := &htmlNode{tag: name, attrs: map[string]string{}}
+	s = s[1+len(name):]
+
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		switch {
+		case s == "":
+			return n, false, s
+		case s[0] == '>':
+			return n, false, s[1:]
+		case strings.HasPrefix(s, "/>"):
+			return n, true, s[2:]
+		case s[0] == '/':
+			s = s[1:]
+			continue
+		}
+
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '=' || r == '>' || r == '/'
+		})
+		if end < 0 {
+			end = len(s)
+		}
+		if end == 0 {
+			// Skip a character that cannot start an attribute
+			s = s[1:]
+			continue
+		}
+		attr := strings.ToLower(s[:end])
+		s = strings.TrimLeftFunc(s[end:], unicode.IsSpace)
+
+		value := ""
+		if strings.HasPrefix(s, "=") {
+			s = strings.TrimLeftFunc(s[1:], unicode.IsSpace)
+			if s != "" && (s[0] == '"' || s[0] == '\'') {
+				quote := s[0]
+				end = strings.IndexByte(s[1:], quote)
+				if end < 0 {
+					end = len(s) - 1
+				}
+				value = s[1 : 1+end]
+				if end+2 < len(s) {
+					s = s[end+2:]
+				} else {
+					s = ""
+				}
+			} else {
+				end = strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == '>' })
+				if end < 0 {
+					end = len(s)
+				}
+				value = s[:end]
+				s = s[end:]
+			}
+		}
+		if _, seen := n.attrs[attr]; !seen {
+			n.attrs[attr] = html.UnescapeString(value)
+		}
+	}
+}
+
+// hasClass reports whether the element's class attribute lists class
+func (n *htmlNode) hasClass(class string) bool {
+	for _, c := range strings.Fields(n.attrs["class"]) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls f on every element below n in document order. Children are
+// skipped when f returns false.
+func (n *htmlNode) walk(f func(*htmlNode) bool) {
+	for _, c := range n.children {
+		if c.tag != "" && f(c) {
+			c.walk(f)
+		}
+	}
+}
+
+// remove detaches the element from its parent
+func (n *htmlNode) remove() {
+	if n.parent == nil {
+		return
+	}
+	siblings := n.parent.children
+	for i, c := range siblings {
+		if c == n {
+			n.parent.children = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	n.parent = nil
+}
+
+// textContent returns the visible text of an element with its whitespace collapsed
+func (n *htmlNode) textContent() string {
+	var b strings.Builder
+	var collect func(*htmlNode)
+	collect = func(n *htmlNode) {
+		switch {
+		case n.tag == "":
+			b.WriteString(n.text)
+			return
+		case n.tag == "script" || n.tag == "style" || n.tag == "template":
+			return
+		case blockElements[n.tag] || n.tag == "br":
+			b.WriteByte(' ')
+			defer b.WriteByte(' ')
+		}
+		for _, c := range n.children {
+			collect(c)
+		}
+	}
+	collect(n)
+	return CollapseWhitespace(b.String())
+}
+
+// cssSelector is a group of comma separated selectors
+type cssSelector [][]cssCompound
+
+// cssCompound is a simple selector such as div.note#intro[lang], together with
+// how it relates to the compound before it
+type cssCompound struct {
+	// combinator is ' ' for a descendant or '>' for a child of the previous compound
+	combinator byte
+	tag        string
+	id         string
+	classes    []string
+	attrs      []cssAttr
+}
+
+// cssAttr is an attribute selector such as [lang] or [href^="http"]
+type cssAttr struct {
+	name, op, value string
+}
+
+// parseSelector parses the subset of CSS selectors made of tag, #id, .class
+// and [attribute] selectors joined by descendant and child combinators
+func parseSelector(s string) (cssSelector, error) {
+	var group cssSelector
+	for _, part := range splitSelectorGroup(s) {
+		complex, err := parseComplexSelector(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %s", strings.TrimSpace(part), err)
+		}
+		group = append(group, complex)
+	}
+	return group, nil
+}
+
+func parseComplexSelector(s string) ([]cssCompound, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	var compounds []cssCompound
+	combinator := byte(' ')
+	for s != "" {
+		switch {
+		case s[0] == ' ' || s[0] == '\t' || s[0] == '\n':
+			s = s[1:]
+			continue
+		case s[0] == '>':
+			if len(compounds) == 0 || combinator == '>' {
+				return nil, fmt.Errorf("unexpected >")
+			}
+			combinator = '>'
+			s = s[1:]
+			continue
+		case s[0] == '+' || s[0] == '~' || s[0] == ':':
+			return nil, fmt.Errorf("%q is not supported", s[0])
+		}
+
+		c := cssCompound{combinator: combinator}
+		combinator = ' '
+		for s != "" && !strings.ContainsRune(" \t\n>+~", rune(s[0])) {
+			switch s[0] {
+			case '*':
+				s = s[1:]
+			case '#', '.':
+				name, rest := cssName(s[1:])
+				if name == "" {
+					return nil, fmt.Errorf("missing name after %q", s[0])
+				}
+				if s[0] == '#' {
+					c.id = name
+				} else {
+					c.classes = append(c.classes, name)
+				}
+				s = rest
+			case '[':
+				end := closingBracket(s)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated [")
+				}
+				attr, err := parseAttrSelector(s[1:end])
+				if err != nil {
+					return nil, err
+				}
+				c.attrs = append(c.attrs, attr)
+				s = s[end+1:]
+			case ':':
+				return nil, fmt.Errorf("pseudo-classes are not supported")
+			default:
+				name, rest := cssName(s)
+				if name == "" {
+					return nil, fmt.Errorf("unexpected %q", s[0])
+				}
+				c.tag = strings.ToLower(name)
+				s = rest
+			}
+		}
+		compounds = append(compounds, c)
+	}
+
+	if combinator == '>' {
+		return nil, fmt.Errorf("nothing after >")
+	}
+	return compounds, nil
+}
+
+// splitSelectorGroup splits a selector group at the commas that are not
+// inside an attribute selector
+func splitSelectorGroup(s string) []string {
+	var parts []string
+	for {
+		i := 0
+		for i < len(s) && s[i] != ',' {
+			if s[i] == '[' {
+				end := closingBracket(s[i:])
+				if end < 0 {
+					// Let parseComplexSelector report it
+					i = len(s)
+					break
+				}
+				i += end
+			}
+			i++
+		}
+		if i >= len(s) {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+1:]
+	}
+}
+
+// closingBracket returns the index of the ] that closes the attribute
+// selector at the start of s, skipping quoted values, or -1
+func closingBracket(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// cssName reads an identifier at the start of s
+func cssName(s string) (string, string) {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_')
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	return s[:end], s[end:]
+}
+
+func parseAttrSelector(s string) (cssAttr, error) {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		name := strings.ToLower(strings.TrimSpace(s))
+		if name == "" {
+			return cssAttr{}, fmt.Errorf("empty attribute selector")
+		}
+		return cssAttr{name: name}, nil
+	}
+
+	op, name := "=", s[:i]
+	if i > 0 && strings.IndexByte("~^$*", s[i-1]) >= 0 {
+		op, name = s[i-1:i+1], s[:i-1]
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return cssAttr{}, fmt.Errorf("missing attribute name before %s", op)
+	}
+
+	value := strings.TrimSpace(s[i+1:])
+	if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+		value = value[1 : n-1]
+	}
+	return cssAttr{name: name, op: op, value: value}, nil
+}
+
+// matches reports whether the element matches any selector in the group
+func (sel cssSelector) matches(n *htmlNode) bool {
+	for _, complex := range sel {
+		if matchComplex(n, complex) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchComplex matches the last compound against n and the others, right to
+// left, against its ancestors
+func matchComplex(n *htmlNode, compounds []cssCompound) bool {
+	last := compounds[len(compounds)-1]
+	if !last.matches(n) {
+		return false
+	}
+	if len(compounds) == 1 {
+		return true
+	}
+
+	rest := compounds[:len(compounds)-1]
+	for p := n.parent; p != nil && p.tag != "#document"; p = p.parent {
+		if matchComplex(p, rest) {
+			return true
+		}
+		if last.combinator == '>' {
+			return false
+		}
+	}
+	return false
+}
+
+func (c cssCompound) matches(n *htmlNode) bool {
+	if c.tag != "" && c.tag != n.tag {
+		return false
+	}
+	if c.id != "" && n.attrs["id"] != c.id {
+		return false
+	}
+	for _, class := range c.classes {
+		if !n.hasClass(class) {
+			return false
+		}
+	}
+	for _, a := range c.attrs {
+		v, ok := n.attrs[a.name]
+		if !ok {
+			return false
+		}
+		switch a.op {
+		case "=":
+			ok = v == a.value
+		case "~=":
+			ok = false
+			for _, word := range strings.Fields(v) {
+				ok = ok || word == a.value
+			}
+		case "^=":
+			ok = strings.HasPrefix(v, a.value)
+		case "$=":
+			ok = strings.HasSuffix(v, a.value)
+		case "*=":
+			ok = strings.Contains(v, a.value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// querySelectorAll returns the elements below n that match the selector, in document order
+func (n *htmlNode) querySelectorAll(sel cssSelector) []*htmlNode {
+	var found []*htmlNode
+	n.walk(func(e *htmlNode) bool {
+		if sel.matches(e) {
+			found = append(found, e)
+		}
+		return true
+	})
+	return found
+}
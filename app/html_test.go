@@ -0,0 +1,236 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outline renders the element tree below n, one element per line, indented
+// by depth, with the text of its direct text children
+func outline(n *htmlNode) string {
+	var b strings.Builder
+	var write func(*htmlNode, int)
+	write = func(n *htmlNode, depth int) {
+		for _, c := range n.children {
+			if c.tag == "" {
+				if text := strings.TrimSpace(c.text); text != "" {
+					b.WriteString(strings.Repeat("  ", depth) + "'" + text + "'\n")
+				}
+				continue
+			}
+			b.WriteString(strings.Repeat("  ", depth) + c.tag + "\n")
+			write(c, depth+1)
+		}
+	}
+	write(n, 0)
+	return b.String()
+}
+
+func TestParseHTML(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"nested",
+			"<div><p>Hello <b>world</b></p></div>",
+			"div\n  p\n    'Hello'\n    b\n      'world'\n",
+		},
+		{
+			"implicitly closed",
+			"<ul><li>one<li>two</ul><p>a<div>b</div>",
+			"ul\n  li\n    'one'\n  li\n    'two'\np\n  'a'\ndiv\n  'b'\n",
+		},
+		{
+			"table cells",
+			"<table><tr><td>a<td>b<tr><th>c</table>",
+			"table\n  tr\n    td\n      'a'\n    td\n      'b'\n  tr\n    th\n      'c'\n",
+		},
+		{
+			"void and self-closing",
+			"<p>a<br>b<img src=x.png/><span/>c</p>",
+			"p\n  'a'\n  br\n  'b'\n  img\n  span\n  'c'\n",
+		},
+		{
+			"raw text",
+			"<script>if (a < b) { x = '</div>' }</script><p>after</p>",
+			"script\n  'if (a < b) { x = '</div>' }'\np\n  'after'\n",
+		},
+		{
+			"comments, doctype and stray end tags",
+			"<!DOCTYPE html><!-- <p>hidden</p> --></span><p>shown</p></div>",
+			"p\n  'shown'\n",
+		},
+		{
+			"lone less-than",
+			"<p>1 < 2</p>",
+			"p\n  '1'\n  '<'\n  '2'\n",
+		},
+		{
+			"unclosed",
+			"<section><h2>Title",
+			"section\n  h2\n    'Title'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := outline(parseHTML(tt.in)); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseHTMLRawTextUntilEndTag(t *testing.T) {
+	doc := parseHTML("<script>var s = '<p>';</SCRIPT><p>after</p>")
+	script := doc.children[0]
+	if script.tag != "script" || len(script.children) != 1 || script.children[0].text != "var s = '<p>';" {
+		t.Errorf("script = %q", outline(script))
+	}
+	if len(doc.children) != 2 || doc.children[1].tag != "p" {
+		t.Errorf("got\n%s", outline(doc))
+	}
+}
+
+func TestParseStartTagAttributes(t *testing.T) {
+	doc := parseHTML(`<a HREF="/a?x=1&amp;y=2" title='it"s' data-x = plain checked id=one id=two class="b  a">x</a>`)
+	a := doc.children[0]
+	want := map[string]string{
+		"href":    "/a?x=1&y=2",
+		"title":   `it"s`,
+		"data-x":  "plain",
+		"checked": "",
+		"id":      "one",
+		"class":   "b  a",
+	}
+	if !reflect.DeepEqual(a.attrs, want) {
+		t.Errorf("attrs = %v, want %v", a.attrs, want)
+	}
+	if !a.hasClass("a") || !a.hasClass("b") || a.hasClass("c") {
+		t.Errorf("hasClass is wrong for %q", a.attrs["class"])
+	}
+}
+
+func TestTextContent(t *testing.T) {
+	doc := parseHTML("<div>Fish&nbsp;&amp; <b>chips</b><p>and</p>peas<br>now<script>x()</script><style>p{}</style></div>")
+	if got, want := doc.textContent(), "Fish & chips and peas now"; got != want {
+		t.Errorf("textContent = %q, want %q", got, want)
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		in   string
+		want cssSelector
+	}{
+		{"h1", cssSelector{{{combinator: ' ', tag: "h1"}}}},
+		{"DIV.note.wide#intro", cssSelector{{{combinator: ' ', tag: "div", id: "intro", classes: []string{"note", "wide"}}}}},
+		{"article > h2 a", cssSelector{{
+			{combinator: ' ', tag: "article"},
+			{combinator: '>', tag: "h2"},
+			{combinator: ' ', tag: "a"},
+		}}},
+		{"h2, h3 ,.x", cssSelector{
+			{{combinator: ' ', tag: "h2"}},
+			{{combinator: ' ', tag: "h3"}},
+			{{combinator: ' ', classes: []string{"x"}}},
+		}},
+		{`[title="a,b"], [data-x='c]d'] , *[lang]`, cssSelector{
+			{{combinator: ' ', attrs: []cssAttr{{name: "title", op: "=", value: "a,b"}}}},
+			{{combinator: ' ', attrs: []cssAttr{{name: "data-x", op: "=", value: "c]d"}}}},
+			{{combinator: ' ', attrs: []cssAttr{{name: "lang"}}}},
+		}},
+		{`a[href^="http"][rel~=nofollow][HREF$=".pdf"][title*="a=b"]`, cssSelector{{{
+			combinator: ' ',
+			tag:        "a",
+			attrs: []cssAttr{
+				{name: "href", op: "^=", value: "http"},
+				{name: "rel", op: "~=", value: "nofollow"},
+				{name: "href", op: "$=", value: ".pdf"},
+				{name: "title", op: "*=", value: "a=b"},
+			},
+		}}}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSelector(tt.in)
+		if err != nil {
+			t.Errorf("parseSelector(%q): %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSelector(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	tests := []struct {
+		in, err string
+	}{
+		{"", "empty selector"},
+		{"h1,", "empty selector"},
+		{"> p", "unexpected >"},
+		{"div >", "nothing after >"},
+		{"div > > p", "unexpected >"},
+		{"a:hover", "pseudo-classes are not supported"},
+		{"h1 + p", "is not supported"},
+		{"h1 ~ p", "is not supported"},
+		{"#", "missing name after"},
+		{"[title", "unterminated ["},
+		{`[title="a]`, "unterminated ["},
+		{"[]", "empty attribute selector"},
+		{"[=x]", "missing attribute name"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseSelector(tt.in)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("parseSelector(%q) error = %v, want %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestQuerySelectorAll(t *testing.T) {
+	doc := parseHTML(`<main>
+		<article class="post" lang="en">
+			<h2 id="a">A</h2>
+			<div class="body"><h2 id="b">B</h2><p><a href="https://x.org/doc.pdf" rel="external nofollow" title="a,b">x</a></p></div>
+		</article>
+		<aside><h2 id="c">C</h2></aside>
+	</main>`)
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"h2", []string{"#a", "#b", "#c"}},
+		{"article h2", []string{"#a", "#b"}},
+		{"article > h2", []string{"#a"}},
+		{"main > article > div > h2, aside h2", []string{"#b", "#c"}},
+		{".post[lang=en] > .body h2", []string{"#b"}},
+		{"#c", []string{"#c"}},
+		{`a[title="a,b"]`, []string{"a"}},
+		{`a[href^="https"][href$=".pdf"][href*="x.org"][rel~="nofollow"]`, []string{"a"}},
+		{`a[rel~="follow"]`, nil},
+		{"aside > p", nil},
+	}
+
+	for _, tt := range tests {
+		sel, err := parseSelector(tt.sel)
+		if err != nil {
+			t.Errorf("parseSelector(%q): %s", tt.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range doc.querySelectorAll(sel) {
+			if id := n.attrs["id"]; id != "" {
+				got = append(got, "#"+id)
+			} else {
+				got = append(got, n.tag)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("querySelectorAll(%q) = %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+}
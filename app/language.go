@@ -0,0 +1,106 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// defaultIndexTemplate names the index of a language that has no index of its own
+const defaultIndexTemplate = "{{.Index}}_{{.Lang}}"
+
+// Language is one language of a multilingual site, with its own records and index
+type Language struct {
+	// Lang is the language code, such as en, de or ja
+	Lang string `mapstructure:"lang"`
+	// File is the JSON file of records for the language
+	File string `mapstructure:"file"`
+	// Index is the index of the language, named from the index template by default
+	Index string `mapstructure:"index"`
+}
+
+// LanguageSettings returns the index settings that tune plurals, stop words
+// and segmentation to a language. Regional codes such as en-us use the
+// settings of their language.
+func LanguageSettings(lang string) algoliasearch.Map {
+	code := strings.ToLower(strings.SplitN(strings.Replace(lang, "_", "-", -1), "-", 2)[0])
+	languages := []string{code}
+	return algoliasearch.Map{
+		"ignorePlurals":   languages,
+		"removeStopWords": languages,
+		"queryLanguages":  languages,
+		"indexLanguages":  languages,
+	}
+}
+
+// LanguageConfigs returns a copy of the configuration for every configured
+// language, each reading its language's file into its language's index. The
+// dry run operations of the copies are reported along with those of c.
+func (c *Config) LanguageConfigs() ([]*Config, error) {
+	if c.FromContent || c.FromPublic {
+		return nil, fmt.Errorf("languages read their records from files, so they cannot be used with --from-content or --from-public")
+	}
+
+	text := c.IndexTemplate
+	if text == "" {
+		text = defaultIndexTemplate
+	}
+	tmpl, err := template.New("index_template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("index_template: %s", err)
+	}
+
+	configs := make([]*Config, 0, len(c.Languages))
+	seen := map[string]string{}
+	for i, l := range c.Languages {
+		if l.Lang == "" {
+			return nil, fmt.Errorf("language %d: missing lang", i)
+		}
+		if l.File == "" {
+			return nil, fmt.Errorf("language %s: missing file", l.Lang)
+		}
+
+		index := l.Index
+		if index == "" {
+			var b bytes.Buffer
+			data := map[string]string{"Index": c.AlgoliaIndexName, "Lang": l.Lang}
+			if err = tmpl.Execute(&b, data); err != nil {
+				return nil, fmt.Errorf("index_template: %s", err)
+			}
+			index = b.String()
+		}
+		if other, dup := seen[index]; dup {
+			return nil, fmt.Errorf("languages %s and %s both use the index %s", other, l.Lang, index)
+		}
+		seen[index] = l.Lang
+
+		lc := *c
+		lc.Lang = l.Lang
+		lc.UploadFile = l.File
+		lc.AlgoliaIndexName = index
+		lc.Languages = nil
+		// Every copy records its own dry run operations
+		lc.dryRun = nil
+		lc.languages = nil
+		configs = append(configs, &lc)
+	}
+	c.languages = configs
+	return configs, nil
+}
+
+// LanguageConfig returns the copy of the configuration for one configured language
+func (c *Config) LanguageConfig(lang string) (*Config, error) {
+	configs, err := c.LanguageConfigs()
+	if err != nil {
+		return nil, err
+	}
+	for _, lc := range configs {
+		if lc.Lang == lang {
+			return lc, nil
+		}
+	}
+	return nil, fmt.Errorf("no language %s in the config file", lang)
+}
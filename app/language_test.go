@@ -0,0 +1,120 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLanguageConfigs(t *testing.T) {
+	c := &Config{
+		AlgoliaIndexName: "docs",
+		Languages: []Language{
+			{Lang: "en", File: "public/index.json"},
+			{Lang: "ja", File: "public/ja/index.json", Index: "docs_japanese"},
+		},
+	}
+	configs, err := c.LanguageConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]string
+	for _, lc := range configs {
+		got = append(got, []string{lc.Lang, lc.UploadFile, lc.AlgoliaIndexName})
+		if lc.Languages != nil {
+			t.Errorf("%s: the copy still has languages", lc.Lang)
+		}
+	}
+	want := [][]string{
+		{"en", "public/index.json", "docs_en"},
+		{"ja", "public/ja/index.json", "docs_japanese"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LanguageConfigs = %v, want %v", got, want)
+	}
+}
+
+func TestLanguageConfigsErrors(t *testing.T) {
+	tests := []struct {
+		config Config
+		err    string
+	}{
+		{Config{Languages: []Language{{File: "a.json"}}}, "language 0: missing lang"},
+		{Config{Languages: []Language{{Lang: "en"}}}, "language en: missing file"},
+		{Config{IndexTemplate: "{{.Missing}}", Languages: []Language{{Lang: "en", File: "a.json"}}}, "index_template"},
+		{Config{IndexTemplate: "docs", Languages: []Language{{Lang: "en", File: "a.json"}, {Lang: "de", File: "b.json"}}}, "languages en and de both use the index docs"},
+		{Config{FromContent: true, Languages: []Language{{Lang: "en", File: "a.json"}}}, "--from-content"},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.config.LanguageConfigs(); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("LanguageConfigs of %+v: error = %v, want %q", tt.config.Languages, err, tt.err)
+		}
+	}
+}
+
+func TestLanguageConfig(t *testing.T) {
+	c := &Config{
+		AlgoliaIndexName: "docs",
+		Languages:        []Language{{Lang: "en", File: "en.json"}, {Lang: "de", File: "de.json"}},
+	}
+	lc, err := c.LanguageConfig("de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lc.UploadFile != "de.json" || lc.AlgoliaIndexName != "docs_de" {
+		t.Errorf("LanguageConfig(de) reads %s into %s", lc.UploadFile, lc.AlgoliaIndexName)
+	}
+
+	if _, err = c.LanguageConfig("fr"); err == nil || err.Error() != "no language fr in the config file" {
+		t.Errorf("LanguageConfig(fr): error = %v", err)
+	}
+}
+
+func TestLanguageConfigsDryRun(t *testing.T) {
+	c := &Config{
+		DryRun:           true,
+		AlgoliaIndexName: "docs",
+		Languages:        []Language{{Lang: "en", File: "en.json"}, {Lang: "de", File: "de.json"}},
+	}
+	c.GetClient()
+	configs, err := c.LanguageConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lc := range configs {
+		if _, err = lc.GetIndex().Clear(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = configs[1].GetIndex().Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(configs[0].DryRunOperations()); n != 1 {
+		t.Errorf("en recorded %d operations, want 1", n)
+	}
+	if n := len(configs[1].DryRunOperations()); n != 2 {
+		t.Errorf("de recorded %d operations, want 2", n)
+	}
+
+	var indexes []string
+	for _, op := range c.DryRunOperations() {
+		indexes = append(indexes, op.Index)
+	}
+	if want := []string{"docs_en", "docs_de", "docs_de"}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("the configuration reports operations on %v, want %v", indexes, want)
+	}
+}
+
+func TestLoadObjectsRejectsLanguages(t *testing.T) {
+	c := &Config{UploadFile: "index.json", Languages: []Language{{Lang: "en", File: "en.json"}}}
+	if _, err := c.LoadObjects(); err == nil || !strings.Contains(err.Error(), "one language at a time") {
+		t.Errorf("LoadObjects: error = %v, want the languages to be rejected", err)
+	}
+	if _, err := c.ValidateObjects(); err == nil {
+		t.Errorf("ValidateObjects checked the records of no language")
+	}
+}
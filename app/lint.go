@@ -0,0 +1,230 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// Severities of lint findings, from the most severe
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+var severityRanks = map[string]int{SeverityError: 3, SeverityWarning: 2, SeverityInfo: 1}
+
+// LintConfig configures the lint rules run over the records
+type LintConfig struct {
+	// Rules maps rule names to their settings. Rules that are not listed run
+	// with their defaults.
+	Rules map[string]LintRuleConfig `mapstructure:"rules"`
+}
+
+// LintRuleConfig are the settings of one lint rule. The rule's defaults
+// apply to the settings left empty.
+type LintRuleConfig struct {
+	Enabled  *bool  `mapstructure:"enabled"`
+	Severity string `mapstructure:"severity"`
+	// Threshold is the limit of rules that count, such as the fewest words of
+	// short-content. A pointer, like Enabled, so that 0 can be configured.
+	Threshold *int `mapstructure:"threshold"`
+	// Attributes are the attributes the rule looks at
+	Attributes []string `mapstructure:"attributes"`
+	// Patterns are the regular expressions the rule looks for
+	Patterns []string `mapstructure:"patterns"`
+}
+
+// LintRule is a check of the records. Rules are registered by name with
+// RegisterLintRule.
+type LintRule struct {
+	Description string
+	// Defaults are the settings of the rule when it is not configured
+	Defaults LintRuleConfig
+	// Check reports the problems it finds in the records
+	Check func(ctx *LintContext, rule LintRuleConfig, report LintReporter) error
+}
+
+// LintReporter reports a problem with record i
+type LintReporter func(i int, format string, args ...interface{})
+
+// LintContext holds the records being linted, grouped into the pages they
+// were built from
+type LintContext struct {
+	Objects []algoliasearch.Object
+	// Pages lists the records of every page, in order. Records split from one
+	// page, and the DocSearch records of one page, belong to the same page.
+	Pages [][]int
+	// PageOf is the index in Pages of the page of every record
+	PageOf []int
+}
+
+// LintFinding is a problem a lint rule found with a record
+type LintFinding struct {
+	Index    int    `json:"index"`
+	ObjectID string `json:"objectID,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (f LintFinding) String() string {
+	location := fmt.Sprintf("record %d", f.Index)
+	if f.URL != "" {
+		location += " (" + f.URL + ")"
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", location, f.Severity, f.Rule, f.Message)
+}
+
+// AtLeast reports whether the finding is at least as severe as severity
+func (f LintFinding) AtLeast(severity string) bool {
+	return severityRanks[f.Severity] >= severityRanks[severity]
+}
+
+// ValidSeverity reports whether s is a known severity
+func ValidSeverity(s string) bool {
+	_, ok := severityRanks[s]
+	return ok
+}
+
+var lintRules = map[string]LintRule{}
+
+// RegisterLintRule adds a rule to those run by Lint
+func RegisterLintRule(name string, rule LintRule) {
+	lintRules[name] = rule
+}
+
+// LintRules returns the registered rules by name
+func LintRules() map[string]LintRule {
+	return lintRules
+}
+
+// LintRuleNames returns the names of the registered rules in order
+func LintRuleNames() []string {
+	names := make([]string, 0, len(lintRules))
+	for name := range lintRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// settings returns the configuration of a rule over its defaults
+func (l LintConfig) settings(name string, rule LintRule) LintRuleConfig {
+	s := rule.Defaults
+	c := l.Rules[name]
+	if c.Enabled != nil {
+		s.Enabled = c.Enabled
+	}
+	if c.Severity != "" {
+		s.Severity = c.Severity
+	}
+	if c.Threshold != nil {
+		s.Threshold = c.Threshold
+	}
+	if c.Attributes != nil {
+		s.Attributes = c.Attributes
+	}
+	if c.Patterns != nil {
+		s.Patterns = c.Patterns
+	}
+	return s
+}
+
+// Check reports configuration of unknown rules or invalid severities
+func (l LintConfig) Check() error {
+	for name, c := range l.Rules {
+		if _, ok := lintRules[name]; !ok {
+			return fmt.Errorf("lint: unknown rule %q", name)
+		}
+		if c.Severity != "" && !ValidSeverity(c.Severity) {
+			return fmt.Errorf("lint: %s: unknown severity %q, expected error, warning or info", name, c.Severity)
+		}
+	}
+	return nil
+}
+
+// Lint runs the enabled rules over the records and returns their findings
+// ordered by record. distinctKey is the attribute that the records split from
+// one page share.
+func (l LintConfig) Lint(objects []algoliasearch.Object, distinctKey string) ([]LintFinding, error) {
+	if err := l.Check(); err != nil {
+		return nil, err
+	}
+
+	ctx := newLintContext(objects, distinctKey)
+	var findings []LintFinding
+	for _, name := range LintRuleNames() {
+		rule := lintRules[name]
+		settings := l.settings(name, rule)
+		if settings.Enabled != nil && !*settings.Enabled {
+			continue
+		}
+		severity := settings.Severity
+		if severity == "" {
+			severity = SeverityWarning
+		}
+
+		report := func(i int, format string, args ...interface{}) {
+			id, _ := objects[i]["objectID"].(string)
+			findings = append(findings, LintFinding{
+				Index:    i,
+				ObjectID: id,
+				URL:      ObjectURL(objects[i]),
+				Rule:     name,
+				Severity: severity,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+		if err := rule.Check(ctx, settings, report); err != nil {
+			return nil, fmt.Errorf("lint: %s: %s", name, err)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Index < findings[j].Index })
+	return findings, nil
+}
+
+func newLintContext(objects []algoliasearch.Object, distinctKey string) *LintContext {
+	ctx := &LintContext{Objects: objects, PageOf: make([]int, len(objects))}
+	pages := map[string]int{}
+	for i, o := range objects {
+		key := pageKey(o, distinctKey)
+		p, ok := pages[key]
+		if !ok || key == "" {
+			p = len(ctx.Pages)
+			pages[key] = p
+			ctx.Pages = append(ctx.Pages, nil)
+		}
+		ctx.Pages[p] = append(ctx.Pages[p], i)
+		ctx.PageOf[i] = p
+	}
+	return ctx
+}
+
+// pageKey identifies the page a record was built from
+func pageKey(o algoliasearch.Object, distinctKey string) string {
+	for _, attr := range []string{distinctKey, "url_without_anchor", "objectID"} {
+		if s, ok := o[attr].(string); ok && s != "" {
+			return attr + "\x00" + s
+		}
+	}
+	return ""
+}
+
+// compilePatterns compiles the patterns of a rule
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %s", p, err)
+		}
+		res[i] = re
+	}
+	return res, nil
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// ruleFindings returns the findings of one rule, each prefixed with its severity
+func ruleFindings(findings []LintFinding, rule string) []string {
+	var got []string
+	for _, f := range findings {
+		if f.Rule == rule {
+			got = append(got, f.Severity+" "+strings.TrimPrefix(f.String(), "record "))
+		}
+	}
+	return got
+}
+
+// onlyRule returns a configuration running the named rule alone
+func onlyRule(name string, c LintRuleConfig) LintConfig {
+	off := false
+	rules := map[string]LintRuleConfig{}
+	for _, n := range LintRuleNames() {
+		rules[n] = LintRuleConfig{Enabled: &off}
+	}
+	on := true
+	c.Enabled = &on
+	rules[name] = c
+	return LintConfig{Rules: rules}
+}
+
+func TestLintRules(t *testing.T) {
+	words := func(n int) string { return strings.Repeat("word ", n) }
+	tests := []struct {
+		rule    string
+		config  LintRuleConfig
+		objects []algoliasearch.Object
+		want    []string
+	}{
+		{
+			"missing-title", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "title": "A"},
+				{"objectID": "b", "title": " "},
+				{"objectID": "c", "hierarchy": map[string]interface{}{"lvl0": "Docs"}},
+			},
+			[]string{"error 1: error: missing-title: missing or empty title"},
+		},
+		{
+			"missing-content", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "content": "text"},
+				{"objectID": "b#0", "parentID": "b", "content": ""},
+				{"objectID": "b#1", "parentID": "b", "content": "text"},
+				{"objectID": "c", "content": []interface{}{}},
+			},
+			[]string{"error 3: error: missing-content: missing or empty content"},
+		},
+		{
+			"short-content", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "content": words(50)},
+				{"objectID": "b", "content": words(49)},
+				{"objectID": "c", "content": ""},
+			},
+			[]string{"warning 1: warning: short-content: 49 words of content, fewer than 50"},
+		},
+		{
+			"short-content", LintRuleConfig{Threshold: intPtr(3)},
+			[]algoliasearch.Object{
+				{"objectID": "a#0", "parentID": "a", "content": "one"},
+				{"objectID": "a#1", "parentID": "a", "content": "two three"},
+				{"objectID": "b", "content": "one two"},
+			},
+			[]string{"warning 2: warning: short-content: 2 words of content, fewer than 3"},
+		},
+		{
+			"short-content", LintRuleConfig{Threshold: intPtr(0)},
+			[]algoliasearch.Object{{"objectID": "a", "content": "one"}},
+			nil,
+		},
+		{
+			"duplicate-permalink", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a#0", "parentID": "a", "permalink": "/a/"},
+				{"objectID": "a#1", "parentID": "a", "permalink": "/a/"},
+				{"objectID": "b#0", "parentID": "b", "permalink": "/a/"},
+				{"objectID": "b#1", "parentID": "b", "permalink": "/a/"},
+			},
+			[]string{"error 2 (/a/): error: duplicate-permalink: same URL /a/ as record 0"},
+		},
+		{
+			"duplicate-title", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "title": "Install"},
+				{"objectID": "b", "title": " install "},
+				{"objectID": "c", "title": "Other"},
+			},
+			[]string{`warning 1: warning: duplicate-title: same title "install" as record 0`},
+		},
+		{
+			"relative-url", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "permalink": "https://example.com/a/"},
+				{"objectID": "b", "permalink": "/b/"},
+				{"objectID": "c"},
+			},
+			[]string{
+				"warning 1 (/b/): warning: relative-url: URL /b/ is not absolute",
+				"warning 2: warning: relative-url: no URL",
+			},
+		},
+		{
+			"draft-marker", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "title": "TODO: write this", "description": "FIXME"},
+				{"objectID": "b", "title": "Ok", "content": "Set XXX to your key"},
+				{"objectID": "c", "title": "Ok", "draft": true},
+				{"objectID": "d", "description": "A [Draft] page"},
+			},
+			[]string{
+				`error 0: error: draft-marker: title contains the marker "TODO"`,
+				"error 2: error: draft-marker: marked as a draft",
+				`error 3: error: draft-marker: description contains the marker "[Draft]"`,
+			},
+		},
+		{
+			"draft-marker", LintRuleConfig{Attributes: []string{"content"}},
+			[]algoliasearch.Object{{"objectID": "b", "title": "Ok", "content": "Set XXX to your key"}},
+			[]string{`error 0: error: draft-marker: content contains the marker "XXX"`},
+		},
+		{
+			"placeholder", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "content": "Lorem ipsum dolor"},
+				{"objectID": "b", "description": "n/a"},
+				{"objectID": "c", "description": "Not applicable"},
+			},
+			[]string{
+				`warning 0: warning: placeholder: content contains placeholder text "Lorem ipsum"`,
+				`warning 1: warning: placeholder: description contains placeholder text "n/a"`,
+			},
+		},
+		{
+			"not-found-page", LintRuleConfig{},
+			[]algoliasearch.Object{
+				{"objectID": "a", "permalink": "https://example.com/404.html", "title": "Oops"},
+				{"objectID": "b", "title": "Page Not Found"},
+				{"objectID": "c", "title": "Finding not found errors"},
+			},
+			[]string{
+				"error 0 (https://example.com/404.html): error: not-found-page: looks like a 404 page",
+				`error 1: error: not-found-page: title "Page Not Found" looks like a 404 page`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		findings, err := onlyRule(tt.rule, tt.config).Lint(tt.objects, "parentID")
+		if err != nil {
+			t.Errorf("%s: %s", tt.rule, err)
+			continue
+		}
+		if got := ruleFindings(findings, tt.rule); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %+v:\ngot  %q\nwant %q", tt.rule, tt.config, got, tt.want)
+		}
+		if len(findings) != len(tt.want) {
+			t.Errorf("%s: other rules ran: %v", tt.rule, findings)
+		}
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	objects := []algoliasearch.Object{
+		{"objectID": "a#0", "parentID": "a", "permalink": "https://example.com/a/", "content": "TODO"},
+		{"objectID": "a#1", "parentID": "a", "permalink": "https://example.com/a/", "content": "TODO"},
+	}
+
+	// By default the page is missing its title once, not once per record
+	findings, err := LintConfig{}.Lint(objects, "parentID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleFindings(findings, "missing-title"); len(got) != 1 {
+		t.Errorf("missing-title findings = %q, want one for the page", got)
+	}
+	if got := ruleFindings(findings, "draft-marker"); len(got) != 0 {
+		t.Errorf("draft-marker searched the content by default: %q", got)
+	}
+
+	off := false
+	c := LintConfig{Rules: map[string]LintRuleConfig{
+		"missing-title": {Severity: SeverityInfo},
+		"short-content": {Enabled: &off},
+	}}
+	if findings, err = c.Lint(objects, "parentID"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ruleFindings(findings, "missing-title"); len(got) != 1 || !strings.HasPrefix(got[0], "info ") {
+		t.Errorf("missing-title findings = %q, want one at info", got)
+	}
+	if got := ruleFindings(findings, "short-content"); len(got) != 0 {
+		t.Errorf("disabled rule reported %q", got)
+	}
+	for i := 1; i < len(findings); i++ {
+		if findings[i].Index < findings[i-1].Index {
+			t.Errorf("findings are not ordered by record: %v", findings)
+		}
+	}
+
+	settings := c.settings("short-content", lintRules["short-content"])
+	if settings.Threshold == nil || *settings.Threshold != 50 || len(settings.Attributes) != 1 {
+		t.Errorf("short-content settings = %+v, want its defaults", settings)
+	}
+}
+
+func TestLintConfigErrors(t *testing.T) {
+	tests := []struct {
+		config LintConfig
+		err    string
+	}{
+		{LintConfig{Rules: map[string]LintRuleConfig{"no-such-rule": {}}}, `unknown rule "no-such-rule"`},
+		{LintConfig{Rules: map[string]LintRuleConfig{"missing-title": {Severity: "fatal"}}}, `unknown severity "fatal"`},
+		{LintConfig{Rules: map[string]LintRuleConfig{"placeholder": {Patterns: []string{"("}}}}, `placeholder: pattern "("`},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.config.Lint(nil, ""); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Lint with %+v: error = %v, want %q", tt.config, err, tt.err)
+		}
+	}
+}
+
+func TestLintFindingAtLeast(t *testing.T) {
+	f := LintFinding{Severity: SeverityWarning}
+	if !f.AtLeast(SeverityInfo) || !f.AtLeast(SeverityWarning) || f.AtLeast(SeverityError) {
+		t.Errorf("AtLeast is wrong for a warning")
+	}
+}
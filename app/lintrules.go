@@ -0,0 +1,258 @@
+package app
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/spf13/cast"
+)
+
+// notFoundURL matches the URLs Hugo renders its 404 page at
+var notFoundURL = regexp.MustCompile(`/404(\.html?)?/?$`)
+
+func init() {
+	RegisterLintRule("missing-title", LintRule{
+		Description: "Pages without a title",
+		Defaults:    LintRuleConfig{Severity: SeverityError, Attributes: []string{"title"}},
+		Check:       lintMissingTitle,
+	})
+	RegisterLintRule("missing-content", LintRule{
+		Description: "Pages without any content",
+		Defaults:    LintRuleConfig{Severity: SeverityError, Attributes: []string{"content"}},
+		Check:       lintMissingContent,
+	})
+	RegisterLintRule("short-content", LintRule{
+		Description: "Pages with fewer words of content than the threshold",
+		Defaults:    LintRuleConfig{Severity: SeverityWarning, Threshold: intPtr(50), Attributes: []string{"content"}},
+		Check:       lintShortContent,
+	})
+	RegisterLintRule("duplicate-permalink", LintRule{
+		Description: "Pages with the URL of another page",
+		Defaults:    LintRuleConfig{Severity: SeverityError},
+		Check:       lintDuplicatePermalink,
+	})
+	RegisterLintRule("duplicate-title", LintRule{
+		Description: "Pages with the title of another page",
+		Defaults:    LintRuleConfig{Severity: SeverityWarning, Attributes: []string{"title"}},
+		Check:       lintDuplicateTitle,
+	})
+	RegisterLintRule("relative-url", LintRule{
+		Description: "Records without an absolute URL",
+		Defaults:    LintRuleConfig{Severity: SeverityWarning},
+		Check:       lintRelativeURL,
+	})
+	// The content of technical pages can mention TODO or XXX for real, so only
+	// the title and description are searched unless configured otherwise
+	RegisterLintRule("draft-marker", LintRule{
+		Description: "Pages marked as drafts or with TODO markers in their title or description",
+		Defaults: LintRuleConfig{
+			Severity:   SeverityError,
+			Attributes: []string{"title", "description"},
+			Patterns:   []string{`\b(TODO|FIXME|TBD|XXX)\b`, `(?i)\[draft\]`},
+		},
+		Check: lintDraftMarker,
+	})
+	RegisterLintRule("placeholder", LintRule{
+		Description: "Pages with placeholder text",
+		Defaults: LintRuleConfig{
+			Severity:   SeverityWarning,
+			Attributes: []string{"title", "description", "content"},
+			Patterns: []string{
+				`(?i)lorem ipsum`,
+				`(?i)\bcoming soon\b`,
+				`(?i)^\s*(placeholder|description|summary|tbd|todo|n/?a|\.\.\.|-)\s*$`,
+			},
+		},
+		Check: lintPlaceholder,
+	})
+	RegisterLintRule("not-found-page", LintRule{
+		Description: "404 and other stub pages that should not be indexed",
+		Defaults: LintRuleConfig{
+			Severity:   SeverityError,
+			Attributes: []string{"title"},
+			Patterns:   []string{`(?i)^\s*(404\b|page not found\b|not found\b)`},
+		},
+		Check: lintNotFoundPage,
+	})
+}
+
+// intPtr returns a pointer to n, for the defaults of rule thresholds
+func intPtr(n int) *int {
+	return &n
+}
+
+// attributeText returns the text of an attribute, with the items of a list joined
+func attributeText(o algoliasearch.Object, attribute string) string {
+	return strings.Join(facetValues(getAttribute(o, attribute)), " ")
+}
+
+// firstText returns the first non-blank value of the attributes in the records of a page
+func (ctx *LintContext) firstText(page []int, attributes []string) (int, string) {
+	for _, i := range page {
+		for _, attr := range attributes {
+			if s := strings.TrimSpace(attributeText(ctx.Objects[i], attr)); s != "" {
+				return i, s
+			}
+		}
+	}
+	return -1, ""
+}
+
+func lintMissingTitle(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	for _, page := range ctx.Pages {
+		if i, _ := ctx.firstText(page, rule.Attributes); i >= 0 {
+			continue
+		}
+		// DocSearch records take their title from their headings
+		if strings.TrimSpace(HitTitle(algoliasearch.Map(ctx.Objects[page[0]]))) == "" {
+			report(page[0], "missing or empty title")
+		}
+	}
+	return nil
+}
+
+func lintMissingContent(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	for _, page := range ctx.Pages {
+		if i, _ := ctx.firstText(page, rule.Attributes); i < 0 {
+			report(page[0], "missing or empty %s", strings.Join(rule.Attributes, ", "))
+		}
+	}
+	return nil
+}
+
+func lintShortContent(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	threshold := 0
+	if rule.Threshold != nil {
+		threshold = *rule.Threshold
+	}
+
+	for _, page := range ctx.Pages {
+		words := 0
+		for _, i := range page {
+			for _, attr := range rule.Attributes {
+				words += len(strings.Fields(attributeText(ctx.Objects[i], attr)))
+			}
+		}
+		// Pages without content are reported by missing-content
+		if words > 0 && words < threshold {
+			report(page[0], "%d words of content, fewer than %d", words, threshold)
+		}
+	}
+	return nil
+}
+
+func lintDuplicatePermalink(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	first := map[string]int{}
+	reported := map[int]bool{}
+	for i, o := range ctx.Objects {
+		u := ObjectURL(o)
+		if u == "" {
+			continue
+		}
+		j, seen := first[u]
+		if !seen {
+			first[u] = i
+			continue
+		}
+		if p := ctx.PageOf[i]; p != ctx.PageOf[j] && !reported[p] {
+			reported[p] = true
+			report(i, "same URL %s as record %d", u, j)
+		}
+	}
+	return nil
+}
+
+func lintDuplicateTitle(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	first := map[string]int{}
+	for _, page := range ctx.Pages {
+		i, title := ctx.firstText(page, rule.Attributes)
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(CollapseWhitespace(title))
+		if j, seen := first[key]; seen {
+			report(i, "same title %q as record %d", title, j)
+		} else {
+			first[key] = i
+		}
+	}
+	return nil
+}
+
+func lintRelativeURL(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	for _, page := range ctx.Pages {
+		for _, i := range page {
+			u := ObjectURL(ctx.Objects[i])
+			if u == "" {
+				report(i, "no URL")
+				break
+			}
+			if parsed, err := url.Parse(u); err != nil || !parsed.IsAbs() || parsed.Host == "" {
+				report(i, "URL %s is not absolute", u)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// lintPatterns reports the first record of every page whose attributes match
+// one of the rule's patterns, with a message formatted from the attribute and
+// the matched text
+func lintPatterns(ctx *LintContext, rule LintRuleConfig, report LintReporter, message string, skip func(page []int) bool) error {
+	patterns, err := compilePatterns(rule.Patterns)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range ctx.Pages {
+		if skip != nil && skip(page) {
+			continue
+		}
+	Page:
+		for _, i := range page {
+			for _, attr := range rule.Attributes {
+				text := attributeText(ctx.Objects[i], attr)
+				if text == "" {
+					continue
+				}
+				for _, re := range patterns {
+					if loc := re.FindStringIndex(text); loc != nil {
+						report(i, message, attr, truncate(strings.TrimSpace(text[loc[0]:loc[1]]), 40))
+						break Page
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func lintDraftMarker(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	return lintPatterns(ctx, rule, report, "%s contains the marker %q", func(page []int) bool {
+		for _, i := range page {
+			if cast.ToBool(ctx.Objects[i]["draft"]) {
+				report(i, "marked as a draft")
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func lintPlaceholder(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	return lintPatterns(ctx, rule, report, "%s contains placeholder text %q", nil)
+}
+
+func lintNotFoundPage(ctx *LintContext, rule LintRuleConfig, report LintReporter) error {
+	return lintPatterns(ctx, rule, report, "%s %q looks like a 404 page", func(page []int) bool {
+		u := ObjectURL(ctx.Objects[page[0]])
+		if parsed, err := url.Parse(u); err == nil && notFoundURL.MatchString(parsed.Path) {
+			report(page[0], "looks like a 404 page")
+			return true
+		}
+		return false
+	})
+}
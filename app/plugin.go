@@ -0,0 +1,124 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+)
+
+// defaultPluginTimeout is how long a plugin may run when no timeout is configured
+const defaultPluginTimeout = time.Minute
+
+// Plugin is an external command that transforms records. It receives the
+// records as newline delimited JSON on stdin and writes the transformed
+// records the same way to stdout.
+type Plugin struct {
+	// Name identifies the plugin in messages, the command by default
+	Name string `mapstructure:"name"`
+	// Command is the program to run, looked up in the PATH
+	Command string `mapstructure:"command"`
+	// Args are passed to the command
+	Args []string `mapstructure:"args"`
+	// Timeout is how long the command may run, one minute by default
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Plugins is an ordered list of plugins, each receiving the output of the previous one
+type Plugins []Plugin
+
+func (p Plugin) name() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Command
+}
+
+// Run passes the records through every plugin in order
+func (ps Plugins) Run(objects []algoliasearch.Object) ([]algoliasearch.Object, error) {
+	for _, p := range ps {
+		var err error
+		if objects, err = p.Run(objects); err != nil {
+			return nil, fmt.Errorf("plugin %s: %s", p.name(), err)
+		}
+	}
+	return objects, nil
+}
+
+// Run sends the records to the plugin and returns the records it writes back.
+// The plugin fails when it exits with an error, runs past its timeout or
+// writes anything but JSON objects.
+func (p Plugin) Run(objects []algoliasearch.Object) ([]algoliasearch.Object, error) {
+	if p.Command == "" {
+		return nil, fmt.Errorf("missing command")
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+
+	var stdin, stdout, stderr bytes.Buffer
+	enc := json.NewEncoder(&stdin)
+	for _, o := range objects {
+		if err := enc.Encode(o); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := exec.Command(p.Command, p.Args...)
+	cmd.Stdin = &stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	setProcessGroup(cmd)
+
+	log.Debugf("Running plugin %s on %d records", p.name(), len(objects))
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	// Wait only returns once the output pipes close, which a child the plugin
+	// started can hold open past the plugin itself, so the timeout kills the
+	// whole process group and does not wait for Wait to return.
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case err = <-done:
+	case <-timer.C:
+		if kerr := killProcessGroup(cmd); kerr != nil {
+			log.WithError(kerr).Debugf("Killing plugin %s", p.name())
+		}
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var result []algoliasearch.Object
+	dec := json.NewDecoder(&stdout)
+	for {
+		var o algoliasearch.Object
+		if derr := dec.Decode(&o); derr == io.EOF {
+			break
+		} else if derr != nil {
+			return nil, fmt.Errorf("output record %d: %s", len(result)+1, derr)
+		}
+		if o == nil {
+			return nil, fmt.Errorf("output record %d: not a JSON object", len(result)+1)
+		}
+		result = append(result, o)
+	}
+	return result, nil
+}
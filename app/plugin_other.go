@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package app
+
+import "os/exec"
+
+// setProcessGroup does nothing where process groups are not supported
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command only. Children it started may keep
+// running, but Plugin.Run does not wait for them once the timeout passes.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,71 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestPluginRun(t *testing.T) {
+	objects := []algoliasearch.Object{{"objectID": "a"}, {"objectID": "b"}}
+	tests := []struct {
+		name   string
+		script string
+		want   int
+		err    string
+	}{
+		{"passes records through", "cat", 2, ""},
+		{"drops records", "head -n 1", 1, ""},
+		{"no output", "cat >/dev/null", 0, ""},
+		{"non-zero exit", "cat >/dev/null; echo broken >&2; exit 3", 0, "exit status 3: broken"},
+		{"malformed output", "echo '{\"objectID\":'", 0, "output record 1"},
+		{"not an object", "echo null", 0, "output record 1: not a JSON object"},
+		{"array output", "echo '[1]'", 0, "output record 1"},
+	}
+
+	for _, tt := range tests {
+		p := Plugin{Command: "sh", Args: []string{"-c", tt.script}}
+		got, err := p.Run(objects)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: got %d records, want %d: %v", tt.name, len(got), tt.want, got)
+		}
+	}
+}
+
+func TestPluginRunTimeout(t *testing.T) {
+	// The sleep is a child of the shell and holds its output open, so the
+	// timeout must kill it as well as the shell
+	p := Plugin{Command: "sh", Args: []string{"-c", "sleep 5; cat"}, Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	_, err := p.Run([]algoliasearch.Object{{"objectID": "a"}})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run took %s, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestPluginRunMissingCommand(t *testing.T) {
+	if _, err := (Plugin{}).Run(nil); err == nil {
+		t.Error("a plugin without a command ran")
+	}
+	if _, err := (Plugin{Command: "no-such-plugin-command"}).Run(nil); err == nil {
+		t.Error("a missing command ran")
+	}
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// defaultRelevanceTop is how far down the hits an expected URL may rank
+const defaultRelevanceTop = 10
+
+// relevanceBatchSize is the number of cases sent in one multiple queries request
+const relevanceBatchSize = 50
+
+// RelevanceSuite is a file of queries and what their results are expected to be
+type RelevanceSuite struct {
+	// Index is the index to query, the configured index by default
+	Index string `json:"index"`
+	// Params are query parameters applied to every case
+	Params map[string]interface{} `json:"params"`
+	Cases  []RelevanceCase        `json:"cases"`
+}
+
+// RelevanceCase is a query and the expectations its results must meet
+type RelevanceCase struct {
+	Name   string                 `json:"name"`
+	Query  string                 `json:"query"`
+	Params map[string]interface{} `json:"params"`
+	Expect RelevanceExpectation   `json:"expect"`
+}
+
+// RelevanceExpectation lists what the results of a query must meet. Every
+// expectation that is set is checked.
+type RelevanceExpectation struct {
+	// URL must be among the first Top hits, 10 by default
+	URL string `json:"url"`
+	Top int    `json:"top"`
+	// NoResults expects the query to find nothing
+	NoResults bool `json:"no_results"`
+	// MinHits is the least number of hits the query must find
+	MinHits int `json:"min_hits"`
+	// Facets maps facets to a value that must be among the facet counts
+	Facets map[string]string `json:"facets"`
+}
+
+// RelevanceResult is the outcome of one case
+type RelevanceResult struct {
+	Name     string   `json:"name"`
+	Query    string   `json:"query"`
+	Passed   bool     `json:"passed"`
+	NbHits   int      `json:"nbHits"`
+	Rank     int      `json:"rank,omitempty"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// LoadRelevanceSuite reads a YAML or JSON file of relevance cases
+func LoadRelevanceSuite(file string) (RelevanceSuite, error) {
+	var suite RelevanceSuite
+	if err := ReadDataFile(file, &suite); err != nil {
+		return suite, err
+	}
+
+	for i, c := range suite.Cases {
+		if c.Name == "" {
+			suite.Cases[i].Name = c.Query
+		}
+		e := c.Expect
+		if e.URL == "" && !e.NoResults && e.MinHits == 0 && len(e.Facets) == 0 {
+			return suite, fmt.Errorf("case %d (%s): no expectations", i, suite.Cases[i].Name)
+		}
+		if e.NoResults && (e.URL != "" || e.MinHits > 0 || len(e.Facets) > 0) {
+			return suite, fmt.Errorf("case %d (%s): no_results cannot be combined with other expectations", i, suite.Cases[i].Name)
+		}
+	}
+	return suite, nil
+}
+
+// params builds the query parameters of a case, asking only for what its
+// expectations need
+func (c RelevanceCase) params(common map[string]interface{}) algoliasearch.Map {
+	raw := map[string]interface{}{}
+	for k, v := range common {
+		raw[k] = v
+	}
+	for k, v := range c.Params {
+		raw[k] = v
+	}
+	params := QueryParams(raw)
+	params["query"] = c.Query
+
+	setDefault := func(key string, v interface{}) {
+		if _, ok := params[key]; !ok {
+			params[key] = v
+		}
+	}
+	setDefault("attributesToRetrieve", urlAttributes)
+	setDefault("attributesToHighlight", []string{})
+	setDefault("attributesToSnippet", []string{})
+	if c.Expect.URL != "" {
+		params["hitsPerPage"] = c.Expect.top()
+	}
+	if len(c.Expect.Facets) > 0 {
+		params["facets"] = c.Expect.facetNames()
+	}
+	return params
+}
+
+func (e RelevanceExpectation) top() int {
+	if e.Top <= 0 {
+		return defaultRelevanceTop
+	}
+	return e.Top
+}
+
+// facetNames lists the facets of the expectation in order
+func (e RelevanceExpectation) facetNames() []string {
+	names := make([]string, 0, len(e.Facets))
+	for facet := range e.Facets {
+		names = append(names, facet)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check compares the results of the case's query with its expectations
+func (c RelevanceCase) Check(res algoliasearch.QueryRes) RelevanceResult {
+	result := RelevanceResult{Name: c.Name, Query: c.Query, NbHits: res.NbHits}
+	fail := func(format string, args ...interface{}) {
+		result.Failures = append(result.Failures, fmt.Sprintf(format, args...))
+	}
+
+	e := c.Expect
+	if e.NoResults && res.NbHits > 0 {
+		fail("expected no results, got %d", res.NbHits)
+	}
+	if e.MinHits > 0 && res.NbHits < e.MinHits {
+		fail("expected at least %d hits, got %d", e.MinHits, res.NbHits)
+	}
+
+	if e.URL != "" {
+		want := pagePath(e.URL)
+		for i, hit := range res.Hits {
+			if i >= e.top() {
+				break
+			}
+			if pagePath(ObjectURL(algoliasearch.Object(hit))) == want {
+				result.Rank = i + 1
+				break
+			}
+		}
+		if result.Rank == 0 {
+			fail("%s is not in the top %d", e.URL, e.top())
+		}
+	}
+
+	for _, facet := range e.facetNames() {
+		value := e.Facets[facet]
+		counts, _ := res.Facets[facet].(map[string]interface{})
+		if _, ok := counts[value]; !ok {
+			fail("facet %s has no value %q", facet, value)
+		}
+	}
+
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// RunRelevanceSuite runs every case of the suite against the index and
+// checks the results. The cases are sent in batches of multiple queries.
+func RunRelevanceSuite(client algoliasearch.Client, index string, suite RelevanceSuite) ([]RelevanceResult, error) {
+	if suite.Index != "" {
+		index = suite.Index
+	}
+
+	results := make([]RelevanceResult, 0, len(suite.Cases))
+	for start := 0; start < len(suite.Cases); start += relevanceBatchSize {
+		end := start + relevanceBatchSize
+		if end > len(suite.Cases) {
+			end = len(suite.Cases)
+		}
+		cases := suite.Cases[start:end]
+
+		queries := make([]algoliasearch.IndexedQuery, len(cases))
+		for i, c := range cases {
+			queries[i] = algoliasearch.IndexedQuery{IndexName: index, Params: c.params(suite.Params)}
+		}
+
+		res, err := client.MultipleQueries(queries, "none")
+		if err != nil {
+			return nil, err
+		}
+		if len(res) != len(cases) {
+			return nil, fmt.Errorf("expected %d results, got %d", len(cases), len(res))
+		}
+		for i, c := range cases {
+			results = append(results, c.Check(res[i].QueryRes))
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestLoadRelevanceSuite(t *testing.T) {
+	file, cleanup := tempDataFile(t, "relevance.yaml", `
+index: docs_staging
+params:
+  filters: "lang:en"
+cases:
+  - query: install
+    expect:
+      url: /docs/install/
+      top: 3
+  - name: nonsense
+    query: qwxz
+    expect:
+      no_results: true
+`)
+	defer cleanup()
+
+	suite, err := LoadRelevanceSuite(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RelevanceSuite{
+		Index:  "docs_staging",
+		Params: map[string]interface{}{"filters": "lang:en"},
+		Cases: []RelevanceCase{
+			{Name: "install", Query: "install", Expect: RelevanceExpectation{URL: "/docs/install/", Top: 3}},
+			{Name: "nonsense", Query: "qwxz", Expect: RelevanceExpectation{NoResults: true}},
+		},
+	}
+	if !reflect.DeepEqual(suite, want) {
+		t.Errorf("LoadRelevanceSuite = %+v, want %+v", suite, want)
+	}
+}
+
+func TestLoadRelevanceSuiteErrors(t *testing.T) {
+	tests := []struct {
+		contents string
+		err      string
+	}{
+		{`{"cases": [{"query": "install"}]}`, "case 0 (install): no expectations"},
+		{`{"cases": [{"query": "a", "expect": {"no_results": true, "min_hits": 1}}]}`, "case 0 (a): no_results cannot be combined"},
+		{`{"cases": {}}`, "cannot unmarshal"},
+	}
+
+	for _, tt := range tests {
+		file, cleanup := tempDataFile(t, "relevance.json", tt.contents)
+		if _, err := LoadRelevanceSuite(file); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("LoadRelevanceSuite(%s): error = %v, want %q", tt.contents, err, tt.err)
+		}
+		cleanup()
+	}
+}
+
+func TestRelevanceCaseParams(t *testing.T) {
+	common := map[string]interface{}{"filters": "lang:en", "hitsPerPage": float64(50)}
+	tests := []struct {
+		name string
+		c    RelevanceCase
+		want algoliasearch.Map
+	}{
+		{
+			"min hits",
+			RelevanceCase{Query: "go", Params: map[string]interface{}{"filters": "lang:de"}, Expect: RelevanceExpectation{MinHits: 1}},
+			algoliasearch.Map{
+				"query": "go", "filters": "lang:de", "hitsPerPage": 50,
+				"attributesToRetrieve": urlAttributes, "attributesToHighlight": []string{}, "attributesToSnippet": []string{},
+			},
+		},
+		{
+			"url and facets",
+			RelevanceCase{
+				Query:  "go",
+				Params: map[string]interface{}{"attributesToRetrieve": []interface{}{"url"}},
+				Expect: RelevanceExpectation{URL: "/a/", Facets: map[string]string{"tags": "go", "lang": "en"}},
+			},
+			algoliasearch.Map{
+				"query": "go", "filters": "lang:en", "hitsPerPage": defaultRelevanceTop, "facets": []string{"lang", "tags"},
+				"attributesToRetrieve": []string{"url"}, "attributesToHighlight": []string{}, "attributesToSnippet": []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.params(common); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: params = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRelevanceCaseCheck(t *testing.T) {
+	hits := func(urls ...string) []algoliasearch.Map {
+		var hits []algoliasearch.Map
+		for _, u := range urls {
+			hits = append(hits, algoliasearch.Map{"permalink": u})
+		}
+		return hits
+	}
+	res := algoliasearch.QueryRes{
+		NbHits: 3,
+		Hits:   hits("https://example.com/a/", "https://example.com/b/index.html", "https://example.com/c/"),
+		Facets: algoliasearch.Map{"tags": map[string]interface{}{"go": 2}},
+	}
+
+	tests := []struct {
+		expect   RelevanceExpectation
+		rank     int
+		failures []string
+	}{
+		{RelevanceExpectation{URL: "/b/"}, 2, nil},
+		{RelevanceExpectation{URL: "https://example.com/c"}, 3, nil},
+		{RelevanceExpectation{URL: "/c/", Top: 2}, 0, []string{"/c/ is not in the top 2"}},
+		{RelevanceExpectation{MinHits: 3}, 0, nil},
+		{RelevanceExpectation{MinHits: 4}, 0, []string{"expected at least 4 hits, got 3"}},
+		{RelevanceExpectation{NoResults: true}, 0, []string{"expected no results, got 3"}},
+		{RelevanceExpectation{Facets: map[string]string{"tags": "go"}}, 0, nil},
+		{
+			RelevanceExpectation{Facets: map[string]string{"tags": "rust", "lang": "en"}},
+			0, []string{`facet lang has no value "en"`, `facet tags has no value "rust"`},
+		},
+	}
+
+	for _, tt := range tests {
+		c := RelevanceCase{Name: "case", Query: "go", Expect: tt.expect}
+		got := c.Check(res)
+		want := RelevanceResult{Name: "case", Query: "go", Passed: tt.failures == nil, NbHits: 3, Rank: tt.rank, Failures: tt.failures}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Check with %+v = %+v, want %+v", tt.expect, got, want)
+		}
+	}
+}
+
+func TestRunRelevanceSuite(t *testing.T) {
+	staging := &fakeIndex{name: "docs_staging", results: map[string]algoliasearch.QueryRes{
+		"case 0": {NbHits: 1, Hits: []algoliasearch.Map{{"url": "/install/"}}},
+	}}
+	client := newFakeClient(staging)
+
+	suite := RelevanceSuite{Index: "docs_staging"}
+	for n := 0; n < relevanceBatchSize+1; n++ {
+		query := fmt.Sprintf("case %d", n)
+		suite.Cases = append(suite.Cases, RelevanceCase{Name: query, Query: query, Expect: RelevanceExpectation{URL: "/install/"}})
+	}
+
+	results, err := RunRelevanceSuite(client, "docs", suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(suite.Cases) {
+		t.Fatalf("got %d results, want %d", len(results), len(suite.Cases))
+	}
+	if !results[0].Passed || results[0].Rank != 1 || results[1].Passed || results[relevanceBatchSize].Name != "case 50" {
+		t.Errorf("results = %+v ...", results[:2])
+	}
+	if want := []string{"multipleQueries 50", "multipleQueries 1"}; !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+	for _, q := range client.queries {
+		if q.IndexName != "docs_staging" {
+			t.Fatalf("queried %s, want the index of the suite", q.IndexName)
+		}
+	}
+
+	client.fail = []string{"multipleQueries"}
+	if _, err = RunRelevanceSuite(client, "docs", suite); err == nil {
+		t.Errorf("RunRelevanceSuite did not return the error of the queries")
+	}
+}
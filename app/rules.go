@@ -0,0 +1,143 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// rulesPerPage is the page size used when retrieving query rules
+const rulesPerPage = 1000
+
+// RulesDiff lists the objectIDs of the query rules that differ between a rules file and the index
+type RulesDiff struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// Empty reports whether the rules file and the index hold the same rules
+func (d RulesDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// GetRules retrieves every query rule of the index, page by page
+func GetRules(index algoliasearch.Index) ([]algoliasearch.Rule, error) {
+	var rules []algoliasearch.Rule
+	for page := 0; ; page++ {
+		res, err := index.SearchRules(algoliasearch.Map{
+			"query":       "",
+			"page":        page,
+			"hitsPerPage": rulesPerPage,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range res.Hits {
+			r.HighlightResult = nil
+			rules = append(rules, r)
+		}
+
+		if page+1 >= res.NbPages {
+			return rules, nil
+		}
+	}
+}
+
+// LoadRulesFile reads a list of query rules from a YAML or JSON file
+func LoadRulesFile(file string) ([]algoliasearch.Rule, error) {
+	var rules []algoliasearch.Rule
+	if err := ReadDataFile(file, &rules); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(rules))
+	for i, r := range rules {
+		if r.ObjectID == "" {
+			return nil, fmt.Errorf("rule %d: missing objectID", i)
+		}
+		if seen[r.ObjectID] {
+			return nil, fmt.Errorf("rule %d: duplicate objectID %q", i, r.ObjectID)
+		}
+		seen[r.ObjectID] = true
+		params, err := ruleParams(r.Consequence.Params)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %s", r.ObjectID, err)
+		}
+		rules[i].Consequence.Params = params
+	}
+	return rules, nil
+}
+
+// ReplaceRules atomically replaces all the query rules of the index with the
+// given ones and waits until they are live
+func ReplaceRules(index algoliasearch.Index, rules []algoliasearch.Rule, forwardToReplicas bool) error {
+	res, err := index.BatchRules(rules, forwardToReplicas, true)
+	if err != nil {
+		return err
+	}
+	return index.WaitTask(res.TaskID)
+}
+
+// DiffRules compares local query rules against remote ones by objectID
+func DiffRules(local, remote []algoliasearch.Rule) RulesDiff {
+	var diff RulesDiff
+
+	remoteByID := make(map[string]interface{}, len(remote))
+	for _, r := range remote {
+		remoteByID[r.ObjectID] = normalizeValue(r)
+	}
+
+	localIDs := make(map[string]bool, len(local))
+	for _, r := range local {
+		localIDs[r.ObjectID] = true
+		remoteRule, exists := remoteByID[r.ObjectID]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, r.ObjectID)
+		case !reflect.DeepEqual(normalizeValue(r), remoteRule):
+			diff.Modified = append(diff.Modified, r.ObjectID)
+		}
+	}
+
+	for id := range remoteByID {
+		if !localIDs[id] {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+// ruleParams converts the decoded consequence parameters of a rule into the
+// Go types the Algolia client checks them against. The client can only send
+// the words to remove from the query, so other query edits are rejected
+// rather than dropped.
+func ruleParams(raw algoliasearch.Map) (algoliasearch.Map, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	params := QueryParams(raw)
+	if edit, ok := raw["query"].(map[string]interface{}); ok {
+		for k := range edit {
+			if k != "remove" {
+				return nil, fmt.Errorf("query edit %q is not supported, only remove is", k)
+			}
+		}
+		var remove []string
+		if words := edit["remove"]; words != nil {
+			if remove, ok = settingValue(words).([]string); !ok {
+				return nil, fmt.Errorf("query edit remove must be a list of words")
+			}
+		}
+		params["query"] = algoliasearch.QueryIncrementalEdit{Remove: remove}
+	}
+	return params, nil
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestLoadRulesFile(t *testing.T) {
+	file, cleanup := tempDataFile(t, "rules.yaml", `
+- objectID: pin-install
+  condition:
+    pattern: install
+    anchoring: contains
+  consequence:
+    params:
+      query:
+        remove: [how, to]
+      hitsPerPage: 5
+    promote:
+      - objectID: /docs/install/
+        position: 0
+- objectID: rewrite
+  condition:
+    pattern: docs
+    anchoring: is
+  consequence:
+    params:
+      query: documentation
+`)
+	defer cleanup()
+
+	rules, err := LoadRulesFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("LoadRulesFile returned %d rules, want 2", len(rules))
+	}
+
+	want := algoliasearch.Map{
+		"query":       algoliasearch.QueryIncrementalEdit{Remove: []string{"how", "to"}},
+		"hitsPerPage": 5,
+	}
+	if got := rules[0].Consequence.Params; !reflect.DeepEqual(got, want) {
+		t.Errorf("params = %#v, want %#v", got, want)
+	}
+	if got := rules[1].Consequence.Params; !reflect.DeepEqual(got, algoliasearch.Map{"query": "documentation"}) {
+		t.Errorf("params = %#v, want the query kept as a string", got)
+	}
+	promote := []algoliasearch.PromotedObject{{ObjectID: "/docs/install/", Position: 0}}
+	if !reflect.DeepEqual(rules[0].Consequence.Promote, promote) {
+		t.Errorf("promote = %+v, want %+v", rules[0].Consequence.Promote, promote)
+	}
+}
+
+func TestLoadRulesFileErrors(t *testing.T) {
+	tests := []struct {
+		contents string
+		err      string
+	}{
+		{`[{"condition": {"pattern": "a"}}]`, "rule 0: missing objectID"},
+		{`[{"objectID": "a"}, {"objectID": "a"}]`, `rule 1: duplicate objectID "a"`},
+		{
+			`[{"objectID": "a", "consequence": {"params": {"query": {"edits": [{"type": "remove", "delete": "how"}]}}}}]`,
+			`rule a: query edit "edits" is not supported, only remove is`,
+		},
+		{
+			`[{"objectID": "a", "consequence": {"params": {"query": {"remove": "how"}}}}]`,
+			"rule a: query edit remove must be a list of words",
+		},
+	}
+
+	for _, tt := range tests {
+		file, cleanup := tempDataFile(t, "rules.json", tt.contents)
+		if _, err := LoadRulesFile(file); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("LoadRulesFile(%s): error = %v, want %q", tt.contents, err, tt.err)
+		}
+		cleanup()
+	}
+}
+
+func TestGetAndReplaceRules(t *testing.T) {
+	index := &fakeIndex{name: "docs"}
+	client := newFakeClient(index)
+	for n := 0; n < rulesPerPage+1; n++ {
+		index.rules = append(index.rules, algoliasearch.Rule{
+			ObjectID:        fmt.Sprint(n),
+			HighlightResult: algoliasearch.Map{"objectID": "<em>1</em>"},
+		})
+	}
+
+	rules, err := GetRules(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != rulesPerPage+1 {
+		t.Fatalf("GetRules returned %d rules, want %d", len(rules), rulesPerPage+1)
+	}
+	for _, r := range rules {
+		if r.HighlightResult != nil {
+			t.Fatalf("rule %s kept its highlighting", r.ObjectID)
+		}
+	}
+
+	if err = ReplaceRules(index, []algoliasearch.Rule{{ObjectID: "new"}}, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.rules) != 1 || index.rules[0].ObjectID != "new" {
+		t.Errorf("rules = %+v, want only the new rule", index.rules)
+	}
+	want := []string{"searchRules docs 0", "searchRules docs 1", "batchRules docs forward=true clear=true"}
+	if !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	rule := func(id, pattern string) algoliasearch.Rule {
+		return algoliasearch.Rule{ObjectID: id, Condition: algoliasearch.RuleCondition{Pattern: pattern}}
+	}
+	local := []algoliasearch.Rule{rule("same", "a"), rule("changed", "new"), rule("added", "c")}
+	remote := []algoliasearch.Rule{rule("removed", "d"), rule("changed", "old"), rule("same", "a")}
+
+	want := RulesDiff{Added: []string{"added"}, Removed: []string{"removed"}, Modified: []string{"changed"}}
+	if got := DiffRules(local, remote); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffRules = %+v, want %+v", got, want)
+	}
+	if !DiffRules(local, local).Empty() {
+		t.Errorf("DiffRules of the same rules is not empty")
+	}
+}
+
+func TestDiffRulesIgnoresTypes(t *testing.T) {
+	file, cleanup := tempDataFile(t, "rules.json", `[{"objectID": "a", "consequence": {"params": {"query": {"remove": ["how"]}, "hitsPerPage": 5}}}]`)
+	defer cleanup()
+	local, err := LoadRulesFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rules read from the index have the params as decoded JSON
+	remote := []algoliasearch.Rule{{
+		ObjectID: "a",
+		Consequence: algoliasearch.RuleConsequence{Params: algoliasearch.Map{
+			"query":       map[string]interface{}{"remove": []interface{}{"how"}},
+			"hitsPerPage": float64(5),
+		}},
+	}}
+	if diff := DiffRules(local, remote); !diff.Empty() {
+		t.Errorf("DiffRules = %+v, want no difference", diff)
+	}
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/spf13/cast"
+)
+
+// Attribute types supported in a record schema
+const (
+	TypeString      = "string"
+	TypeStringArray = "string[]"
+	TypeInt         = "int"
+	TypeFloat       = "float"
+	TypeBool        = "bool"
+	TypeTimestamp   = "timestamp"
+)
+
+// SchemaField declares the type of one record attribute
+type SchemaField struct {
+	Attribute string `mapstructure:"attribute"`
+	Type      string `mapstructure:"type"`
+	Required  bool   `mapstructure:"required"`
+}
+
+// Schema is the list of attribute types records are coerced to before upload
+type Schema []SchemaField
+
+// Check reports the first field with an unknown type or no attribute name
+func (s Schema) Check() error {
+	for i, f := range s {
+		if f.Attribute == "" {
+			return fmt.Errorf("schema field %d: missing attribute", i)
+		}
+		switch f.Type {
+		case TypeString, TypeStringArray, TypeInt, TypeFloat, TypeBool, TypeTimestamp:
+		default:
+			return fmt.Errorf("schema field %q: unknown type %q", f.Attribute, f.Type)
+		}
+	}
+	return nil
+}
+
+// Coerce converts the attributes of every record to their declared types in
+// place. Records with missing required attributes or values that cannot be
+// converted are reported as violations.
+func (s Schema) Coerce(objects []algoliasearch.Object) ([]Violation, error) {
+	if err := s.Check(); err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	for i, o := range objects {
+		for _, f := range s {
+			v, ok := o[f.Attribute]
+			if !ok || v == nil {
+				if f.Required {
+					violations = append(violations, schemaViolation(i, o, "missing required attribute %q", f.Attribute))
+				}
+				continue
+			}
+
+			coerced, err := coerceValue(v, f.Type)
+			if err != nil {
+				violations = append(violations, schemaViolation(i, o, "attribute %q: %s", f.Attribute, err))
+				continue
+			}
+			o[f.Attribute] = coerced
+		}
+	}
+	return violations, nil
+}
+
+func schemaViolation(i int, o algoliasearch.Object, format string, args ...interface{}) Violation {
+	id, _ := o["objectID"].(string)
+	return Violation{
+		Index:    i,
+		ObjectID: id,
+		URL:      ObjectURL(o),
+		Check:    "schema",
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// coerceValue converts a value to one of the schema types. Values come from
+// decoded JSON, front matter, transforms, computed attributes and plugins, so
+// any Go integer or float type, json.Number and []string are accepted.
+func coerceValue(v interface{}, typ string) (interface{}, error) {
+	v = schemaValue(v)
+
+	switch typ {
+	case TypeString:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case uint64:
+			return strconv.FormatUint(v, 10), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+
+	case TypeStringArray:
+		switch v := v.(type) {
+		case string:
+			// A single string may hold a comma separated list
+			list := []string{}
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					list = append(list, s)
+				}
+			}
+			return list, nil
+		case []interface{}:
+			list := make([]string, 0, len(v))
+			for _, item := range v {
+				s, err := coerceValue(item, TypeString)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, s.(string))
+			}
+			return list, nil
+		}
+
+	case TypeInt:
+		switch v := v.(type) {
+		case float64:
+			if i, ok := floatToInt(v); ok {
+				return i, nil
+			}
+			return nil, fmt.Errorf("%v is not a whole number", v)
+		case int64:
+			return v, nil
+		case uint64:
+			if v <= math.MaxInt64 {
+				return int64(v), nil
+			}
+			return nil, fmt.Errorf("%d is too large", v)
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i, nil
+			}
+		}
+
+	case TypeFloat:
+		switch v := v.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case uint64:
+			return float64(v), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+
+	case TypeBool:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		case int64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		case uint64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "yes", "1":
+				return true, nil
+			case "false", "no", "0":
+				return false, nil
+			}
+		}
+
+	case TypeTimestamp:
+		switch v := v.(type) {
+		case float64:
+			if i, ok := floatToInt(v); ok {
+				return i, nil
+			}
+		case int64:
+			return v, nil
+		case uint64:
+			if v <= math.MaxInt64 {
+				return int64(v), nil
+			}
+		case time.Time:
+			return v.Unix(), nil
+		case string:
+			// Dates become Unix timestamps so they can be used in numeric filters
+			if t, err := cast.StringToDate(strings.TrimSpace(v)); err == nil {
+				return t.Unix(), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to %s", describeValue(v), typ)
+}
+
+// schemaValue reduces the Go types a value may have to those coerceValue
+// handles: signed integers become int64, unsigned integers uint64, floats
+// float64 and lists []interface{}
+func schemaValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, string, bool, float64, int64, uint64, time.Time, []interface{}:
+		return v
+	case float32:
+		return float64(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	}
+	return v
+}
+
+// floatToInt converts a whole number that fits in an int64
+func floatToInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// describeValue renders a value for an error message
+func describeValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprint(v)
+}
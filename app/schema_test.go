@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestCoerceValue(t *testing.T) {
+	date := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   interface{}
+		typ  string
+		want interface{}
+	}{
+		// string
+		{"go", TypeString, "go"},
+		{float64(1.5), TypeString, "1.5"},
+		{3, TypeString, "3"},
+		{int64(-3), TypeString, "-3"},
+		{uint8(7), TypeString, "7"},
+		{float32(0.5), TypeString, "0.5"},
+		{json.Number("42"), TypeString, "42"},
+		{true, TypeString, "true"},
+
+		// string[]
+		{"go, hugo,", TypeStringArray, []string{"go", "hugo"}},
+		{[]interface{}{"go", float64(2)}, TypeStringArray, []string{"go", "2"}},
+		{[]string{"go", "hugo"}, TypeStringArray, []string{"go", "hugo"}},
+		{[]interface{}{1, int32(2)}, TypeStringArray, []string{"1", "2"}},
+
+		// int
+		{float64(5), TypeInt, int64(5)},
+		{5, TypeInt, int64(5)},
+		{int8(-5), TypeInt, int64(-5)},
+		{uint32(5), TypeInt, int64(5)},
+		{float32(5), TypeInt, int64(5)},
+		{json.Number("5"), TypeInt, int64(5)},
+		{" 5 ", TypeInt, int64(5)},
+
+		// float
+		{float64(2.5), TypeFloat, 2.5},
+		{float32(2.5), TypeFloat, 2.5},
+		{2, TypeFloat, float64(2)},
+		{uint(2), TypeFloat, float64(2)},
+		{json.Number("2.5"), TypeFloat, 2.5},
+		{"2.5", TypeFloat, 2.5},
+
+		// bool
+		{true, TypeBool, true},
+		{float64(1), TypeBool, true},
+		{0, TypeBool, false},
+		{uint8(1), TypeBool, true},
+		{"yes", TypeBool, true},
+		{"No", TypeBool, false},
+
+		// timestamp
+		{float64(1519905600), TypeTimestamp, int64(1519905600)},
+		{1519905600, TypeTimestamp, int64(1519905600)},
+		{json.Number("1519905600"), TypeTimestamp, int64(1519905600)},
+		{"2018-03-01T12:00:00Z", TypeTimestamp, date.Unix()},
+		{date, TypeTimestamp, date.Unix()},
+	}
+
+	for _, tt := range tests {
+		got, err := coerceValue(tt.in, tt.typ)
+		if err != nil {
+			t.Errorf("coerceValue(%#v, %s): %s", tt.in, tt.typ, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("coerceValue(%#v, %s) = %#v, want %#v", tt.in, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestCoerceValueErrors(t *testing.T) {
+	tests := []struct {
+		in  interface{}
+		typ string
+	}{
+		{map[string]interface{}{}, TypeString},
+		{[]interface{}{"a"}, TypeString},
+		{[]interface{}{map[string]interface{}{}}, TypeStringArray},
+		{float64(1.5), TypeInt},
+		{float32(1.5), TypeInt},
+		{math.Inf(1), TypeInt},
+		{math.NaN(), TypeInt},
+		{float64(1e20), TypeInt},
+		{uint64(math.MaxUint64), TypeInt},
+		{"five", TypeInt},
+		{true, TypeInt},
+		{"x", TypeFloat},
+		{2, TypeBool},
+		{"maybe", TypeBool},
+		{float64(1.5), TypeTimestamp},
+		{"not a date", TypeTimestamp},
+	}
+
+	for _, tt := range tests {
+		if got, err := coerceValue(tt.in, tt.typ); err == nil {
+			t.Errorf("coerceValue(%#v, %s) = %#v, want an error", tt.in, tt.typ, got)
+		}
+	}
+}
+
+func TestSchemaCoerce(t *testing.T) {
+	schema := Schema{
+		{Attribute: "tags", Type: TypeStringArray},
+		{Attribute: "weight", Type: TypeInt},
+		{Attribute: "title", Type: TypeString, Required: true},
+	}
+	objects := []algoliasearch.Object{
+		{"objectID": "a", "title": "A", "tags": []string{"go", "hugo"}, "weight": 5},
+		{"objectID": "b", "weight": "heavy"},
+	}
+
+	violations, err := schema.Coerce(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(objects[0]["tags"], []string{"go", "hugo"}) || objects[0]["weight"] != int64(5) {
+		t.Errorf("record 0 coerced to %v", objects[0])
+	}
+	if len(violations) != 2 || violations[0].Index != 1 || violations[1].Index != 1 {
+		t.Errorf("violations = %v, want two for record 1", violations)
+	}
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// SearchOptions are the query parameters of a search from the command line
+type SearchOptions struct {
+	Filters              string
+	FacetFilters         []string
+	HitsPerPage          int
+	Page                 int
+	AttributesToRetrieve []string
+	// Highlight asks for highlighted matches and a snippet of SnippetAttribute
+	Highlight        bool
+	SnippetAttribute string
+	SnippetWords     int
+	HighlightPreTag  string
+	HighlightPostTag string
+}
+
+// Params converts the options into Algolia query parameters, leaving out
+// those that are not set so the index settings apply
+func (o SearchOptions) Params() algoliasearch.Map {
+	params := algoliasearch.Map{}
+	if o.Filters != "" {
+		params["filters"] = o.Filters
+	}
+	if len(o.FacetFilters) > 0 {
+		params["facetFilters"] = o.FacetFilters
+	}
+	if o.HitsPerPage > 0 {
+		params["hitsPerPage"] = o.HitsPerPage
+	}
+	if o.Page > 0 {
+		params["page"] = o.Page
+	}
+	if len(o.AttributesToRetrieve) > 0 {
+		params["attributesToRetrieve"] = o.AttributesToRetrieve
+	}
+
+	if !o.Highlight {
+		params["attributesToHighlight"] = []string{}
+		params["attributesToSnippet"] = []string{}
+		return params
+	}
+	if o.SnippetAttribute != "" {
+		words := o.SnippetWords
+		if words <= 0 {
+			words = 20
+		}
+		params["attributesToSnippet"] = []string{fmt.Sprintf("%s:%d", o.SnippetAttribute, words)}
+	}
+	if o.HighlightPreTag != "" {
+		params["highlightPreTag"] = o.HighlightPreTag
+	}
+	if o.HighlightPostTag != "" {
+		params["highlightPostTag"] = o.HighlightPostTag
+	}
+	return params
+}
+
+// Search runs a query against the index
+func Search(index algoliasearch.Index, query string, opts SearchOptions) (algoliasearch.QueryRes, error) {
+	return index.Search(query, opts.Params())
+}
+
+// Search runs a query against the configured index
+func (c *Config) Search(query string, opts SearchOptions) (algoliasearch.QueryRes, error) {
+	return Search(c.GetIndex(), query, opts)
+}
+
+// FacetValues returns the most frequent values of a facet among the records
+// that match the query, with their counts
+func FacetValues(index algoliasearch.Index, facet, query string) ([]algoliasearch.FacetHit, error) {
+	res, err := index.SearchForFacetValues(facet, "", algoliasearch.Map{"query": query})
+	if err != nil {
+		return nil, err
+	}
+	return res.FacetHits, nil
+}
+
+// SearchableFacets lists the facets of the configured index whose values can
+// be searched, which are those declared as searchable(attribute)
+func (c *Config) SearchableFacets() ([]string, error) {
+	settings, err := c.GetIndex().GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	var facets []string
+	for _, attr := range settings.AttributesForFaceting {
+		if strings.HasPrefix(attr, "searchable(") && strings.HasSuffix(attr, ")") {
+			facets = append(facets, strings.TrimSuffix(strings.TrimPrefix(attr, "searchable("), ")"))
+		}
+	}
+	return facets, nil
+}
+
+// HitTitle returns the title of a hit, or the headings of a DocSearch record
+func HitTitle(hit algoliasearch.Map) string {
+	if title, ok := hit["title"].(string); ok && title != "" {
+		return title
+	}
+
+	hierarchy, _ := hit["hierarchy"].(map[string]interface{})
+	var parts []string
+	for _, level := range crawlLevels {
+		if s, ok := hierarchy[level].(string); ok && s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
+
+// HitSnippet returns the snippet of an attribute of a hit, falling back to
+// its highlighted value and then to the start of its plain value
+func HitSnippet(hit algoliasearch.Map, attribute string, words int) string {
+	for _, key := range []string{"_snippetResult", "_highlightResult"} {
+		results, _ := hit[key].(map[string]interface{})
+		if result, ok := results[attribute].(map[string]interface{}); ok {
+			if value, ok := result["value"].(string); ok {
+				return CollapseWhitespace(value)
+			}
+		}
+	}
+
+	s := CollapseWhitespace(toString(hit[attribute]))
+	if words <= 0 {
+		words = 20
+	}
+	if fields := strings.Fields(s); len(fields) > words {
+		return strings.Join(fields[:words], " ") + " …"
+	}
+	return s
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// requestParams decodes the query parameters sent in the body of a search request
+func requestParams(r *http.Request) (url.Values, error) {
+	var body struct {
+		Params string `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return url.ParseQuery(body.Params)
+}
+
+func TestSearchOptionsParams(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want algoliasearch.Map
+	}{
+		{
+			"defaults",
+			SearchOptions{},
+			algoliasearch.Map{"attributesToHighlight": []string{}, "attributesToSnippet": []string{}},
+		},
+		{
+			"filters and paging",
+			SearchOptions{Filters: "lang:en", FacetFilters: []string{"section:docs"}, HitsPerPage: 5, Page: 2, AttributesToRetrieve: []string{"title"}},
+			algoliasearch.Map{
+				"filters":               "lang:en",
+				"facetFilters":          []string{"section:docs"},
+				"hitsPerPage":           5,
+				"page":                  2,
+				"attributesToRetrieve":  []string{"title"},
+				"attributesToHighlight": []string{},
+				"attributesToSnippet":   []string{},
+			},
+		},
+		{
+			"highlight with the index settings",
+			SearchOptions{Highlight: true},
+			algoliasearch.Map{},
+		},
+		{
+			"highlight and snippet",
+			SearchOptions{Highlight: true, SnippetAttribute: "content", HighlightPreTag: "[", HighlightPostTag: "]"},
+			algoliasearch.Map{"attributesToSnippet": []string{"content:20"}, "highlightPreTag": "[", "highlightPostTag": "]"},
+		},
+		{
+			"snippet length",
+			SearchOptions{Highlight: true, SnippetAttribute: "content", SnippetWords: 8},
+			algoliasearch.Map{"attributesToSnippet": []string{"content:8"}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.opts.Params(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Params = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var sent url.Values
+	defer withSettingsServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1/indexes/docs/query" {
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusBadRequest)
+			return
+		}
+		var err error
+		if sent, err = requestParams(r); err != nil {
+			http.Error(w, `{"message":"bad body"}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"nbHits": 1, "hits": [{"objectID": "a", "title": "Install"}]}`))
+	})()
+
+	c := Config{AlgoliaAppID: "app", AlgoliaAPIKey: "key", AlgoliaIndexName: "docs"}
+	res, err := c.Search("install", SearchOptions{FacetFilters: []string{"lang:en"}, HitsPerPage: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.NbHits != 1 || HitTitle(res.Hits[0]) != "Install" {
+		t.Errorf("Search = %+v", res)
+	}
+
+	want := url.Values{
+		"query":                 {"install"},
+		"facetFilters":          {`["lang:en"]`},
+		"hitsPerPage":           {"5"},
+		"attributesToHighlight": {"[]"},
+		"attributesToSnippet":   {"[]"},
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("sent %v, want %v", sent, want)
+	}
+}
+
+func TestFacetValuesAndSearchableFacets(t *testing.T) {
+	defer withSettingsServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1/indexes/docs/settings":
+			_, _ = w.Write([]byte(`{"attributesForFaceting": ["lang", "searchable(tags)", "filterOnly(section)", "searchable(author)"]}`))
+		case "/1/indexes/docs/facets/tags/query":
+			params, err := requestParams(r)
+			if err != nil || params.Get("query") != "hugo" || params.Get("facetQuery") != "" {
+				http.Error(w, `{"message":"unexpected params"}`, http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"facetHits": [{"value": "go", "highlighted": "go", "count": 3}]}`))
+		default:
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusBadRequest)
+		}
+	})()
+
+	c := Config{AlgoliaAppID: "app", AlgoliaAPIKey: "key", AlgoliaIndexName: "docs"}
+	facets, err := c.SearchableFacets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"tags", "author"}; !reflect.DeepEqual(facets, want) {
+		t.Errorf("SearchableFacets = %v, want %v", facets, want)
+	}
+
+	values, err := FacetValues(c.GetIndex(), "tags", "hugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []algoliasearch.FacetHit{{Value: "go", Highlighted: "go", Count: 3}}; !reflect.DeepEqual(values, want) {
+		t.Errorf("FacetValues = %+v, want %+v", values, want)
+	}
+}
+
+func TestHitTitle(t *testing.T) {
+	tests := []struct {
+		hit  algoliasearch.Map
+		want string
+	}{
+		{algoliasearch.Map{"title": "Install"}, "Install"},
+		{algoliasearch.Map{"title": "", "hierarchy": map[string]interface{}{"lvl0": "Docs", "lvl1": "Install", "lvl3": "Linux"}}, "Docs > Install > Linux"},
+		{algoliasearch.Map{"hierarchy": map[string]interface{}{"lvl0": "Docs", "lvl1": nil}}, "Docs"},
+		{algoliasearch.Map{"objectID": "a"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := HitTitle(tt.hit); got != tt.want {
+			t.Errorf("HitTitle(%v) = %q, want %q", tt.hit, got, tt.want)
+		}
+	}
+}
+
+func TestHitSnippet(t *testing.T) {
+	tests := []struct {
+		hit   algoliasearch.Map
+		words int
+		want  string
+	}{
+		{
+			algoliasearch.Map{
+				"content":          "plain",
+				"_highlightResult": map[string]interface{}{"content": map[string]interface{}{"value": "highlighted"}},
+				"_snippetResult":   map[string]interface{}{"content": map[string]interface{}{"value": "a  <em>snippet</em>\n…"}},
+			},
+			20, "a <em>snippet</em> …",
+		},
+		{
+			algoliasearch.Map{
+				"content":          "plain",
+				"_highlightResult": map[string]interface{}{"content": map[string]interface{}{"value": "<em>highlighted</em>"}},
+			},
+			20, "<em>highlighted</em>",
+		},
+		{algoliasearch.Map{"content": "one two  three four"}, 3, "one two three …"},
+		{algoliasearch.Map{"content": "one two"}, 0, "one two"},
+		{algoliasearch.Map{}, 3, ""},
+	}
+
+	for _, tt := range tests {
+		if got := HitSnippet(tt.hit, "content", tt.words); got != tt.want {
+			t.Errorf("HitSnippet(%v, %d) = %q, want %q", tt.hit, tt.words, got, tt.want)
+		}
+	}
+}
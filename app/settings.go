@@ -0,0 +1,248 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// SettingChange describes a setting whose value in the settings file differs
+// from the value on the index
+type SettingChange struct {
+	Name   string      `json:"name"`
+	Local  interface{} `json:"local"`
+	Remote interface{} `json:"remote"`
+}
+
+// newHTTPClient returns the HTTP client the Algolia API clients send their
+// requests with, set up like the default of the Algolia client
+var newHTTPClient = func() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			Dial:                (&net.Dialer{Timeout: time.Second, KeepAlive: 3 * time.Minute}).Dial,
+			MaxIdleConnsPerHost: 64,
+			TLSHandshakeTimeout: 2 * time.Second,
+		},
+	}
+}
+
+// newClient returns an Algolia API client sending its requests with httpClient
+func newClient(appID, apiKey string, httpClient *http.Client) algoliasearch.Client {
+	client := algoliasearch.NewClient(appID, apiKey)
+	client.SetHTTPClient(httpClient)
+	return client
+}
+
+// rawBodyTransport keeps the body of the last response it passes on, so that
+// a response can be decoded again after the Algolia client has decoded it
+// into its own types
+type rawBodyTransport struct {
+	http.RoundTripper
+	body []byte
+}
+
+func (t *rawBodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if t.body, err = ioutil.ReadAll(res.Body); err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(t.body))
+	return res, nil
+}
+
+// GetRawSettings returns every setting of the index as the API returns it,
+// in the form accepted by SetSettings. Unlike the Settings of the Algolia
+// client, it keeps the settings the client does not know about, such as
+// queryLanguages or decompoundedAttributes. The request is still sent by the
+// Algolia client, which picks the hosts and retries failed requests.
+func GetRawSettings(appID, apiKey, name string) (algoliasearch.Map, error) {
+	httpClient := newHTTPClient()
+	raw := &rawBodyTransport{RoundTripper: httpClient.Transport}
+	if raw.RoundTripper == nil {
+		raw.RoundTripper = http.DefaultTransport
+	}
+	httpClient.Transport = raw
+
+	if _, err := newClient(appID, apiKey, httpClient).InitIndex(name).GetSettings(); err != nil {
+		return nil, fmt.Errorf("getting the settings: %s", apiErrorMessage(err))
+	}
+
+	var settings map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw.body))
+	dec.UseNumber()
+	if err := dec.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("getting the settings: %s", err)
+	}
+	return SettingsMap(settings), nil
+}
+
+// apiErrorMessage returns the message of an error the Algolia client returns
+// for a failed request, which is the JSON body of the response
+func apiErrorMessage(err error) string {
+	var body struct {
+		Message string `json:"message"`
+		Status  int    `json:"status"`
+	}
+	if json.Unmarshal([]byte(err.Error()), &body) != nil || body.Message == "" {
+		return err.Error()
+	}
+	if body.Status == 0 {
+		return body.Message
+	}
+	return fmt.Sprintf("%s (%d)", body.Message, body.Status)
+}
+
+// LoadSettingsFile reads index settings from a YAML or JSON file
+func LoadSettingsFile(file string) (algoliasearch.Map, error) {
+	var raw map[string]interface{}
+	if err := ReadDataFile(file, &raw); err != nil {
+		return nil, err
+	}
+
+	return SettingsMap(raw), nil
+}
+
+// SettingsMap converts decoded JSON or YAML settings into the Go types the
+// Algolia client expects
+func SettingsMap(raw map[string]interface{}) algoliasearch.Map {
+	settings := algoliasearch.Map{}
+	for k, v := range raw {
+		settings[k] = settingValue(v)
+	}
+	return settings
+}
+
+// PushSettings applies the settings to the index and waits until they are live.
+// Settings not present in the map are left as they are.
+func PushSettings(index algoliasearch.Index, settings algoliasearch.Map) error {
+	res, err := index.SetSettings(settings)
+	if err != nil {
+		return err
+	}
+	return index.WaitTask(res.TaskID)
+}
+
+// keepCustomRanking puts the custom ranking criteria the named index already
+// has in front of the ones in settings, so that pushing settings extends the
+// ranking of the index instead of replacing it. Criteria the index already
+// has are not added twice.
+func keepCustomRanking(client algoliasearch.Client, name string, settings algoliasearch.Map) (algoliasearch.Map, error) {
+	ranking, ok := settings["customRanking"].([]string)
+	if !ok {
+		return settings, nil
+	}
+	exists, err := IndexExists(client, name)
+	if err != nil || !exists {
+		return settings, err
+	}
+	current, err := client.InitIndex(name).GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	merged := append([]string{}, current.CustomRanking...)
+	for _, r := range ranking {
+		found := false
+		for _, c := range current.CustomRanking {
+			if c == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, r)
+		}
+	}
+
+	result := algoliasearch.Map{}
+	for k, v := range settings {
+		result[k] = v
+	}
+	result["customRanking"] = merged
+	return result, nil
+}
+
+// DiffSettings compares every setting in local against remote and returns the
+// ones that differ, sorted by name
+func DiffSettings(local, remote algoliasearch.Map) []SettingChange {
+	var changes []SettingChange
+	for name, value := range local {
+		l, r := normalizeValue(value), normalizeValue(remote[name])
+		if !reflect.DeepEqual(l, r) && !(isEmptyValue(l) && isEmptyValue(r)) {
+			changes = append(changes, SettingChange{Name: name, Local: l, Remote: r})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// settingValue converts a decoded JSON value into the Go type the Algolia
+// client expects for settings: lists of strings become []string and whole
+// numbers become int.
+func settingValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		strs := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return v
+			}
+			strs[i] = s
+		}
+		return strs
+	case float64:
+		if v == float64(int(v)) {
+			return int(v)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// normalizeValue round-trips a value through JSON so that values of different
+// Go types but equal JSON representations compare equal
+func normalizeValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var n interface{}
+	if err = json.Unmarshal(b, &n); err != nil {
+		return v
+	}
+	return n
+}
+
+// isEmptyValue reports whether a normalized value is missing or an empty list.
+// The client leaves empty lists out of the settings it returns.
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	list, ok := v.([]interface{})
+	return ok && len(list) == 0
+}
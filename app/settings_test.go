@@ -0,0 +1,112 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// serverTransport sends every request to a test server, whatever its host
+type serverTransport struct {
+	http.RoundTripper
+	host string
+}
+
+func (t serverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Host = t.host
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// withSettingsServer points the Algolia API requests at handler until the
+// returned function is called
+func withSettingsServer(handler http.HandlerFunc) func() {
+	srv := httptest.NewTLSServer(handler)
+	newClient := newHTTPClient
+	newHTTPClient = func() *http.Client {
+		client := srv.Client()
+		client.Transport = serverTransport{srv.Client().Transport, strings.TrimPrefix(srv.URL, "https://")}
+		return client
+	}
+	return func() {
+		newHTTPClient = newClient
+		srv.Close()
+	}
+}
+
+func TestGetRawSettings(t *testing.T) {
+	defer withSettingsServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1/indexes/docs/settings" || r.Header.Get("X-Algolia-API-Key") != "key" {
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{
+			"searchableAttributes": ["title", "content"],
+			"hitsPerPage": 20,
+			"queryLanguages": ["en", "fr"],
+			"camelCaseAttributes": ["title"],
+			"decompoundedAttributes": {"de": ["title"]},
+			"paginationLimitedTo": 500,
+			"attributeCriteriaComputedByMinProximity": true
+		}`))
+	})()
+
+	settings, err := GetRawSettings("app", "key", "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"searchableAttributes":                    []string{"title", "content"},
+		"hitsPerPage":                             20,
+		"queryLanguages":                          []string{"en", "fr"},
+		"camelCaseAttributes":                     []string{"title"},
+		"decompoundedAttributes":                  map[string]interface{}{"de": []interface{}{"title"}},
+		"paginationLimitedTo":                     500,
+		"attributeCriteriaComputedByMinProximity": true,
+	}
+	for name, value := range want {
+		if !reflect.DeepEqual(settings[name], value) {
+			t.Errorf("%s = %#v, want %#v", name, settings[name], value)
+		}
+	}
+	if len(settings) != len(want) {
+		t.Errorf("got %d settings, want %d: %v", len(settings), len(want), settings)
+	}
+}
+
+func TestGetRawSettingsError(t *testing.T) {
+	defer withSettingsServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Index does not exist","status":404}`))
+	})()
+
+	_, err := GetRawSettings("app", "key", "missing")
+	if err == nil || !strings.Contains(err.Error(), "Index does not exist (404)") {
+		t.Errorf("got error %v, want the API message", err)
+	}
+}
+
+func TestGetRawSettingsRetriesOtherHosts(t *testing.T) {
+	var hosts []string
+	defer withSettingsServer(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		if len(hosts) == 1 {
+			http.Error(w, `{"message":"unavailable","status":503}`, http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"hitsPerPage": 10}`))
+	})()
+
+	settings, err := GetRawSettings("app", "key", "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings["hitsPerPage"] != 10 {
+		t.Errorf("hitsPerPage = %#v, want 10", settings["hitsPerPage"])
+	}
+	if len(hosts) != 2 || hosts[0] == hosts[1] {
+		t.Errorf("requests went to %v, want a second host after the failure", hosts)
+	}
+}
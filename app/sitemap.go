@@ -0,0 +1,243 @@
+package app
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+	"github.com/spf13/cast"
+)
+
+// SitemapConfig configures the sitemap used to select the pages to index
+type SitemapConfig struct {
+	// File is the sitemap or sitemap index, "public/sitemap.xml" under the site directory by default
+	File string `mapstructure:"file"`
+	// LastmodAttribute receives the lastmod of the page as a Unix timestamp, "lastmod" by default
+	LastmodAttribute string `mapstructure:"lastmod_attribute"`
+	// PriorityAttribute receives the priority of the page, "priority" by default
+	PriorityAttribute string `mapstructure:"priority_attribute"`
+	// Ranking appends the priority to the custom ranking of the index, true by default
+	Ranking *bool `mapstructure:"ranking"`
+}
+
+// SitemapEntry is a page listed in a sitemap
+type SitemapEntry struct {
+	Loc      string
+	Lastmod  time.Time
+	Priority *float64
+}
+
+// sitemapXML decodes both a <urlset> and a <sitemapindex>
+type sitemapXML struct {
+	URLs []struct {
+		Loc      string `xml:"loc"`
+		Lastmod  string `xml:"lastmod"`
+		Priority string `xml:"priority"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func (s *SitemapConfig) file(siteDir string) string {
+	file := s.File
+	if file == "" {
+		file = filepath.Join("public", "sitemap.xml")
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(siteDir, file)
+	}
+	return file
+}
+
+func (s *SitemapConfig) lastmodAttribute() string {
+	if s.LastmodAttribute == "" {
+		return "lastmod"
+	}
+	return s.LastmodAttribute
+}
+
+func (s *SitemapConfig) priorityAttribute() string {
+	if s.PriorityAttribute == "" {
+		return "priority"
+	}
+	return s.PriorityAttribute
+}
+
+// Settings returns the index settings that rank pages by their sitemap
+// priority. The criterion is appended to the custom ranking the index
+// already has when the settings are applied.
+func (s *SitemapConfig) Settings() algoliasearch.Map {
+	if s.Ranking != nil && !*s.Ranking {
+		return algoliasearch.Map{}
+	}
+	return algoliasearch.Map{
+		"customRanking": []string{"desc(" + s.priorityAttribute() + ")"},
+	}
+}
+
+// LoadSitemap reads the pages listed in a sitemap. The sitemaps listed in a
+// sitemap index, as Hugo writes for multilingual sites, are read from the
+// same directory tree.
+func LoadSitemap(file string) ([]SitemapEntry, error) {
+	return loadSitemap(file, filepath.Dir(file), map[string]bool{})
+}
+
+func loadSitemap(file, root string, seen map[string]bool) ([]SitemapEntry, error) {
+	if seen[file] {
+		return nil, nil
+	}
+	seen[file] = true
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var doc sitemapXML
+	if err = xml.NewDecoder(f).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	var entries []SitemapEntry
+	for _, u := range doc.URLs {
+		entry := SitemapEntry{Loc: strings.TrimSpace(u.Loc)}
+		if lastmod := strings.TrimSpace(u.Lastmod); lastmod != "" {
+			if entry.Lastmod, err = cast.StringToDate(lastmod); err != nil {
+				return nil, fmt.Errorf("%s: %s: invalid lastmod %q", file, entry.Loc, lastmod)
+			}
+		}
+		if priority := strings.TrimSpace(u.Priority); priority != "" {
+			p, perr := strconv.ParseFloat(priority, 64)
+			if perr != nil {
+				return nil, fmt.Errorf("%s: %s: invalid priority %q", file, entry.Loc, priority)
+			}
+			entry.Priority = &p
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, s := range doc.Sitemaps {
+		child, cerr := sitemapFile(root, strings.TrimSpace(s.Loc))
+		if cerr != nil {
+			return nil, fmt.Errorf("%s: %s", file, cerr)
+		}
+		more, lerr := loadSitemap(child, root, seen)
+		if lerr != nil {
+			return nil, lerr
+		}
+		entries = append(entries, more...)
+	}
+	return entries, nil
+}
+
+// sitemapFile finds the local file of a sitemap listed in an index. The base
+// URL of the site may have a path of its own, so leading directories of the
+// URL are dropped until the file is found.
+func sitemapFile(root, loc string) (string, error) {
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := range parts {
+		file := filepath.Join(root, filepath.FromSlash(strings.Join(parts[i:], "/")))
+		if _, serr := os.Stat(file); serr == nil {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find %s under %s", loc, root)
+}
+
+// pagePath reduces a page URL to its path, so that absolute and relative URLs
+// of the same page compare equal
+func pagePath(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	p := strings.TrimSuffix(u.Path, "index.html")
+	p = strings.TrimSuffix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+// FilterObjects keeps the records of the pages listed in the sitemap and
+// gives them the lastmod and priority of their page. Records without a URL
+// are kept. The pages of the sitemap that have no record are returned.
+func (s *SitemapConfig) FilterObjects(siteDir string, objects []algoliasearch.Object) ([]algoliasearch.Object, []SitemapEntry, error) {
+	kept, missing, err := s.filter(siteDir, objects)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]algoliasearch.Object, len(kept))
+	for i, k := range kept {
+		result[i] = objects[k]
+	}
+	return result, missing, nil
+}
+
+// filter implements FilterObjects, returning the positions of the records kept
+func (s *SitemapConfig) filter(siteDir string, objects []algoliasearch.Object) ([]int, []SitemapEntry, error) {
+	entries, err := LoadSitemap(s.file(siteDir))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := make(map[string]SitemapEntry, len(entries))
+	for _, e := range entries {
+		pages[pagePath(e.Loc)] = e
+	}
+
+	found := map[string]bool{}
+	var kept []int
+	skipped := 0
+	for i, o := range objects {
+		u := ObjectURL(o)
+		if u == "" {
+			kept = append(kept, i)
+			continue
+		}
+
+		p := pagePath(u)
+		entry, ok := pages[p]
+		if !ok {
+			skipped++
+			continue
+		}
+
+		found[p] = true
+		if !entry.Lastmod.IsZero() {
+			o[s.lastmodAttribute()] = float64(entry.Lastmod.Unix())
+		}
+		if entry.Priority != nil {
+			o[s.priorityAttribute()] = *entry.Priority
+		}
+		kept = append(kept, i)
+	}
+	if skipped > 0 {
+		log.WithField("records", skipped).Info("Skipped records not in the sitemap")
+	}
+
+	var missing []SitemapEntry
+	for _, e := range entries {
+		if p := pagePath(e.Loc); !found[p] {
+			found[p] = true
+			missing = append(missing, e)
+		}
+	}
+	return kept, missing, nil
+}
@@ -0,0 +1,185 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// writeSitemaps writes a sitemap index for two languages under dir and
+// returns the path of the index
+func writeSitemaps(t *testing.T, dir string) string {
+	files := map[string]string{
+		"public/sitemap.xml": `<?xml version="1.0" encoding="utf-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/docs/en/sitemap.xml</loc></sitemap>
+  <sitemap><loc>https://example.com/docs/fr/sitemap.xml</loc></sitemap>
+</sitemapindex>`,
+		"public/en/sitemap.xml": `<urlset>
+  <url><loc>https://example.com/docs/en/</loc><lastmod>2018-03-01T10:00:00Z</lastmod><priority>1.0</priority></url>
+  <url><loc> https://example.com/docs/en/guide/ </loc><priority>0.5</priority></url>
+  <url><loc>https://example.com/docs/en/unindexed/</loc></url>
+</urlset>`,
+		"public/fr/sitemap.xml": `<urlset>
+  <url><loc>https://example.com/docs/fr/</loc><lastmod>2018-03-02</lastmod></url>
+</urlset>`,
+	}
+	for name, body := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "public", "sitemap.xml")
+}
+
+func TestLoadSitemap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sitemap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entries, err := LoadSitemap(writeSitemaps(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		loc      string
+		lastmod  int64
+		priority float64
+	}{
+		{"https://example.com/docs/en/", 1519898400, 1},
+		{"https://example.com/docs/en/guide/", 0, 0.5},
+		{"https://example.com/docs/en/unindexed/", 0, -1},
+		{"https://example.com/docs/fr/", 1519948800, -1},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		lastmod := int64(0)
+		if !e.Lastmod.IsZero() {
+			lastmod = e.Lastmod.Unix()
+		}
+		priority := -1.0
+		if e.Priority != nil {
+			priority = *e.Priority
+		}
+		if e.Loc != w.loc || lastmod != w.lastmod || priority != w.priority {
+			t.Errorf("entry %d = %s %d %v, want %s %d %v", i, e.Loc, lastmod, priority, w.loc, w.lastmod, w.priority)
+		}
+	}
+}
+
+func TestLoadSitemapErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sitemap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name, body, err string
+	}{
+		{"lastmod", `<urlset><url><loc>/a/</loc><lastmod>yesterday</lastmod></url></urlset>`, `invalid lastmod "yesterday"`},
+		{"priority", `<urlset><url><loc>/a/</loc><priority>high</priority></url></urlset>`, `invalid priority "high"`},
+		{"missing sitemap", `<sitemapindex><sitemap><loc>https://example.com/none.xml</loc></sitemap></sitemapindex>`, "cannot find https://example.com/none.xml"},
+		{"malformed", `<urlset><url>`, "sitemap.xml"},
+	}
+
+	for _, tt := range tests {
+		file := filepath.Join(dir, "sitemap.xml")
+		if err = ioutil.WriteFile(file, []byte(tt.body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = LoadSitemap(file); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestSitemapFilterObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sitemap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeSitemaps(t, dir)
+
+	objects := []algoliasearch.Object{
+		{"objectID": "home", "permalink": "https://example.com/docs/en/"},
+		{"objectID": "guide", "url": "/docs/en/guide/index.html"},
+		{"objectID": "draft", "permalink": "/docs/en/draft/"},
+		{"objectID": "fr", "permalink": "/docs/fr/"},
+		{"objectID": "nourl"},
+	}
+	config := &SitemapConfig{PriorityAttribute: "weight"}
+	result, missing, err := config.FilterObjects(dir, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []algoliasearch.Object{
+		{"objectID": "home", "permalink": "https://example.com/docs/en/", "lastmod": float64(1519898400), "weight": 1.0},
+		{"objectID": "guide", "url": "/docs/en/guide/index.html", "weight": 0.5},
+		{"objectID": "fr", "permalink": "/docs/fr/", "lastmod": float64(1519948800)},
+		{"objectID": "nourl"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("records = %v, want %v", result, want)
+	}
+	if len(missing) != 1 || missing[0].Loc != "https://example.com/docs/en/unindexed/" {
+		t.Errorf("missing = %v, want the unindexed page", missing)
+	}
+}
+
+func TestSitemapSettingsKeepCustomRanking(t *testing.T) {
+	tests := []struct {
+		name    string
+		current []string
+		want    []string
+	}{
+		{"no ranking", nil, []string{"desc(priority)"}},
+		{"existing ranking", []string{"desc(date)"}, []string{"desc(date)", "desc(priority)"}},
+		{"already ranked", []string{"desc(priority)", "desc(date)"}, []string{"desc(priority)", "desc(date)"}},
+	}
+
+	for _, tt := range tests {
+		live := &fakeIndex{name: "docs", settings: algoliasearch.Map{}}
+		if tt.current != nil {
+			live.settings["customRanking"] = tt.current
+		}
+		client := newFakeClient(live)
+
+		settings, err := keepCustomRanking(client, "docs", (&SitemapConfig{}).Settings())
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if got := settings["customRanking"]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: customRanking = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A new index has no ranking to keep
+	settings, err := keepCustomRanking(newFakeClient(), "docs", (&SitemapConfig{}).Settings())
+	if err != nil || !reflect.DeepEqual(settings["customRanking"], []string{"desc(priority)"}) {
+		t.Errorf("new index: %v, %v", settings, err)
+	}
+
+	off := false
+	if settings := (&SitemapConfig{Ranking: &off}).Settings(); len(settings) != 0 {
+		t.Errorf("settings with ranking off = %v, want none", settings)
+	}
+}
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// SplitConfig configures how long pages are broken into several records
+type SplitConfig struct {
+	// Attribute holds the page content to split, "content" by default
+	Attribute string `mapstructure:"attribute"`
+	// URLAttribute holds the page URL that chunks add their anchor to, "permalink" by default
+	URLAttribute string `mapstructure:"url_attribute"`
+	// DistinctAttribute receives the objectID of the page on every chunk, "parentID" by default
+	DistinctAttribute string `mapstructure:"distinct_attribute"`
+	// MaxSize is the largest chunk of content in bytes, or 0 to only split at headings
+	MaxSize int `mapstructure:"max_size"`
+
+	// normalize is applied to the text of every section, so that the
+	// headings are still in the content when it is split
+	normalize []TextOptions
+}
+
+// section is the part of a page under one heading
+type section struct {
+	heading string
+	anchor  string
+	text    string
+}
+
+var (
+	htmlHeading    = regexp.MustCompile(`(?is)<h[1-6]([^>]*)>(.*?)</h[1-6]\s*>`)
+	htmlIDAttr     = regexp.MustCompile(`(?i)\bid\s*=\s*["']([^"']+)["']`)
+	mdHeadingLine  = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}[ \t]+(.+?)[ \t#]*$`)
+	mdHeadingID    = regexp.MustCompile(`\s*\{#([^}]+)\}$`)
+	mdFenceLine    = regexp.MustCompile(`(?m)^ {0,3}(` + "```+|~~~+" + `)`)
+	sentenceBreaks = []string{". ", "! ", "? ", " "}
+)
+
+func (s *SplitConfig) attribute() string {
+	if s.Attribute == "" {
+		return "content"
+	}
+	return s.Attribute
+}
+
+func (s *SplitConfig) urlAttribute() string {
+	if s.URLAttribute == "" {
+		return "permalink"
+	}
+	return s.URLAttribute
+}
+
+// DistinctKey is the attribute shared by every record built from the same page
+func (s *SplitConfig) DistinctKey() string {
+	if s.DistinctAttribute == "" {
+		return "parentID"
+	}
+	return s.DistinctAttribute
+}
+
+// Settings returns the index settings that make search show one hit per page
+func (s *SplitConfig) Settings() algoliasearch.Map {
+	return algoliasearch.Map{
+		"attributeForDistinct": s.DistinctKey(),
+		"distinct":             true,
+	}
+}
+
+// SplitObjects breaks the content of every object into chunks at headings and
+// at MaxSize, returning one record per chunk
+func (s *SplitConfig) SplitObjects(objects []algoliasearch.Object) ([]algoliasearch.Object, error) {
+	var result []algoliasearch.Object
+	for i, o := range objects {
+		chunks, err := s.SplitObject(o)
+		if err != nil {
+			return nil, fmt.Errorf("object %d: %s", i, err)
+		}
+		result = append(result, chunks...)
+	}
+	return result, nil
+}
+
+// SplitObject breaks a single page into records. Every record keeps the
+// attributes of the page and gets an objectID and URL derived from the page's
+// and the heading's anchor, plus the page's objectID as distinct key.
+func (s *SplitConfig) SplitObject(object algoliasearch.Object) ([]algoliasearch.Object, error) {
+	parentID, err := object.ObjectID()
+	if err != nil {
+		// Leave records without a usable objectID for validation to report
+		return []algoliasearch.Object{object}, nil
+	}
+
+	content, ok := object[s.attribute()].(string)
+	if !ok {
+		// Nothing to split, but the record still needs its distinct key
+		record := copyObject(object)
+		record[s.DistinctKey()] = parentID
+		return []algoliasearch.Object{record}, nil
+	}
+
+	url, _ := object[s.urlAttribute()].(string)
+	var records []algoliasearch.Object
+	anchors := map[string]int{}
+	for _, sec := range s.splitSections(content) {
+		for n, text := range splitText(sec.text, s.MaxSize) {
+			if text == "" && sec.heading == "" {
+				continue
+			}
+
+			anchor := sec.anchor
+			suffix := anchor
+			if suffix == "" {
+				suffix = "0"
+			}
+			if n > 0 {
+				suffix = fmt.Sprintf("%s-%d", suffix, n)
+			}
+			// Headings that repeat on a page still need distinct objectIDs
+			if count := anchors[suffix]; count > 0 {
+				suffix = fmt.Sprintf("%s-%d", suffix, count)
+			}
+			anchors[suffix]++
+
+			record := copyObject(object)
+			record["objectID"] = parentID + "#" + suffix
+			record[s.attribute()] = text
+			record[s.DistinctKey()] = parentID
+			if sec.heading != "" {
+				record["heading"] = sec.heading
+			}
+			if anchor != "" && url != "" {
+				record[s.urlAttribute()] = strings.SplitN(url, "#", 2)[0] + "#" + anchor
+			}
+			records = append(records, record)
+		}
+	}
+
+	if len(records) == 0 {
+		record := copyObject(object)
+		record[s.DistinctKey()] = parentID
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// splitSections breaks content into sections at its HTML or Markdown headings,
+// converting the text of each section to plain text
+func (s *SplitConfig) splitSections(content string) []section {
+	if matches := htmlHeading.FindAllStringSubmatchIndex(content, -1); len(matches) > 0 {
+		toText := s.toText(HTMLToText, false)
+		sections := []section{{text: toText(content[:matches[0][0]])}}
+		for i, m := range matches {
+			end := len(content)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+
+			heading := HTMLToText(content[m[4]:m[5]])
+			anchor := Urlize(heading)
+			if id := htmlIDAttr.FindStringSubmatch(content[m[2]:m[3]]); id != nil {
+				anchor = id[1]
+			}
+			sections = append(sections, section{heading: heading, anchor: anchor, text: toText(content[m[1]:end])})
+		}
+		return sections
+	}
+
+	if matches := markdownHeadings(content); len(matches) > 0 {
+		toText := s.toText(MarkdownToText, true)
+		sections := []section{{text: toText(content[:matches[0][0]])}}
+		for i, m := range matches {
+			end := len(content)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+
+			heading := content[m[2]:m[3]]
+			anchor := ""
+			if id := mdHeadingID.FindStringSubmatch(heading); id != nil {
+				anchor = id[1]
+				heading = heading[:len(heading)-len(id[0])]
+			}
+			heading = MarkdownToText(heading)
+			if anchor == "" {
+				anchor = Urlize(heading)
+			}
+			sections = append(sections, section{heading: heading, anchor: anchor, text: toText(content[m[1]:end])})
+		}
+		return sections
+	}
+
+	return []section{{text: s.toText(CollapseWhitespace, false)(content)}}
+}
+
+// markdownHeadings returns the submatch indexes of the Markdown heading lines
+// in content, skipping lines inside ``` and ~~~ code fences
+func markdownHeadings(content string) [][]int {
+	matches := mdHeadingLine.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// fenced holds the start and end of every fenced block, an unclosed
+	// fence running to the end of the content
+	var fenced [][2]int
+	open, start := "", 0
+	for _, f := range mdFenceLine.FindAllStringSubmatchIndex(content, -1) {
+		fence := content[f[2]:f[3]]
+		switch {
+		case open == "":
+			open, start = fence, f[0]
+		case fence[0] == open[0] && len(fence) >= len(open):
+			fenced = append(fenced, [2]int{start, f[1]})
+			open = ""
+		}
+	}
+	if open != "" {
+		fenced = append(fenced, [2]int{start, len(content)})
+	}
+
+	var headings [][]int
+	for _, m := range matches {
+		inFence := false
+		for _, f := range fenced {
+			if m[0] >= f[0] && m[0] < f[1] {
+				inFence = true
+				break
+			}
+		}
+		if !inFence {
+			headings = append(headings, m)
+		}
+	}
+	return headings
+}
+
+// toText returns the conversion of section text to plain text: the configured
+// normalization when there is one, plain otherwise. Markdown sections are
+// always stripped of their Markdown syntax.
+func (s *SplitConfig) toText(plain func(string) string, markdown bool) func(string) string {
+	if len(s.normalize) == 0 {
+		return plain
+	}
+	return func(text string) string {
+		for _, opts := range s.normalize {
+			opts.Markdown = opts.Markdown || markdown
+			text = NormalizeText(text, opts)
+		}
+		return text
+	}
+}
+
+// splitText breaks text into chunks of at most maxSize bytes, preferring to
+// break between sentences, then words. The text of a section has its
+// whitespace collapsed, so there are no paragraphs left to break between.
+func splitText(text string, maxSize int) []string {
+	if maxSize <= 0 || len(text) <= maxSize {
+		return []string{text}
+	}
+
+	var chunks []string
+	for len(text) > maxSize {
+		cut := -1
+		for _, sep := range sentenceBreaks {
+			// A break just past maxSize still leaves a chunk of maxSize once trimmed
+			if i := strings.LastIndex(text[:maxSize+1], sep); i > 0 {
+				cut = i + len(sep)
+				break
+			}
+		}
+		if cut <= 0 {
+			// No break at all, so cut at the last whole rune that fits
+			cut = maxSize
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				_, cut = utf8.DecodeRuneInString(text)
+			}
+		}
+
+		chunks = append(chunks, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// copyObject returns a shallow copy of a record
+func copyObject(object algoliasearch.Object) algoliasearch.Object {
+	c := make(algoliasearch.Object, len(object))
+	for k, v := range object {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,210 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestSplitStagesKeepHeadings(t *testing.T) {
+	c := Config{
+		Split: &SplitConfig{},
+		Transforms: Transforms{
+			{Type: "strip_html", Attribute: "content"},
+			{Type: "lowercase", Attribute: "summary"},
+		},
+		Normalize: []NormalizeConfig{
+			{Attributes: []string{"content", "summary"}, TextOptions: TextOptions{DropCode: true}},
+		},
+	}
+	objects := []algoliasearch.Object{{
+		"objectID":  "p",
+		"permalink": "/p/",
+		"summary":   "<p>A {{< ref x >}} Page</p>",
+		"content":   `<p>Intro</p><h2 id="a">A</h2><p>One</p><pre>code</pre><h2 id="b">B</h2><p>Two {{< note >}}</p>`,
+	}}
+
+	transforms, normalize, split := c.splitStages()
+	if err := transforms.Apply(objects); err != nil {
+		t.Fatal(err)
+	}
+	NormalizeObjects(normalize, objects)
+	records, err := split.SplitObjects(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ id, content string }{
+		{"p#0", "Intro"},
+		{"p#a", "One"},
+		{"p#b", "Two"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d: %v", len(records), len(want), records)
+	}
+	for i, w := range want {
+		if records[i]["objectID"] != w.id || records[i]["content"] != w.content {
+			t.Errorf("record %d = %v %q, want %v %q", i, records[i]["objectID"], records[i]["content"], w.id, w.content)
+		}
+		if records[i]["summary"] != "a page" {
+			t.Errorf("record %d summary = %q, want %q", i, records[i]["summary"], "a page")
+		}
+	}
+	if len(c.Transforms) != 2 || len(c.Normalize[0].Attributes) != 2 {
+		t.Errorf("splitStages changed the configuration: %v %v", c.Transforms, c.Normalize)
+	}
+}
+
+func TestSplitObjects(t *testing.T) {
+	type chunk struct {
+		id, url, heading, content string
+	}
+	tests := []struct {
+		name   string
+		config SplitConfig
+		object algoliasearch.Object
+		want   []chunk
+	}{
+		{
+			"no headings",
+			SplitConfig{},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/", "content": "  just\n text "},
+			[]chunk{{"p#0", "/p/", "", "just text"}},
+		},
+		{
+			"html headings",
+			SplitConfig{},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/#top", "content": `<p>Intro</p><h2 id="install">Install <code>it</code></h2><p>Run it</p><h3>Next Steps</h3><p>Done</p>`},
+			[]chunk{
+				{"p#0", "/p/#top", "", "Intro"},
+				{"p#install", "/p/#install", "Install it", "Run it"},
+				{"p#next-steps", "/p/#next-steps", "Next Steps", "Done"},
+			},
+		},
+		{
+			"markdown headings",
+			SplitConfig{},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/", "content": "## Set *up* {#setup}\n\nUse my_var.\n\n### Usage ##\n\n- a\n- b\n"},
+			[]chunk{
+				{"p#setup", "/p/#setup", "Set up", "Use my_var."},
+				{"p#usage", "/p/#usage", "Usage", "a b"},
+			},
+		},
+		{
+			"headings in code fences",
+			SplitConfig{},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/", "content": "Intro\n\n## Install\n\n```sh\n# install deps\nnpm i\n```\n\nDone\n\n~~~\n## not\n~~~\n\n## Use\n\nIt"},
+			[]chunk{
+				{"p#0", "/p/", "", "Intro"},
+				{"p#install", "/p/#install", "Install", "Done"},
+				{"p#use", "/p/#use", "Use", "It"},
+			},
+		},
+		{
+			"repeated headings",
+			SplitConfig{},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/", "content": "<h2>Notes</h2><p>a</p><h2>Notes</h2><p>b</p>"},
+			[]chunk{
+				{"p#notes", "/p/#notes", "Notes", "a"},
+				{"p#notes-1", "/p/#notes", "Notes", "b"},
+			},
+		},
+		{
+			"max size",
+			SplitConfig{MaxSize: 12},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/", "content": "<h2 id=\"a\">A</h2><p>One two. Three four.</p>"},
+			[]chunk{
+				{"p#a", "/p/#a", "A", "One two."},
+				{"p#a-1", "/p/#a", "A", "Three four."},
+			},
+		},
+		{
+			"custom attributes",
+			SplitConfig{Attribute: "body", URLAttribute: "url", DistinctAttribute: "page"},
+			algoliasearch.Object{"objectID": "p", "url": "/p/", "content": "kept", "body": "<h2>A</h2>a"},
+			[]chunk{{"p#a", "/p/#a", "A", "kept"}},
+		},
+		{
+			"content is not a string",
+			SplitConfig{},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/", "content": []interface{}{"a"}},
+			[]chunk{{"p", "/p/", "", ""}},
+		},
+		{
+			"empty content",
+			SplitConfig{},
+			algoliasearch.Object{"objectID": "p", "permalink": "/p/", "content": ""},
+			[]chunk{{"p", "/p/", "", ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		records, err := tt.config.SplitObjects([]algoliasearch.Object{tt.object})
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if len(records) != len(tt.want) {
+			t.Errorf("%s: got %d records, want %d: %v", tt.name, len(records), len(tt.want), records)
+			continue
+		}
+
+		for i, w := range tt.want {
+			r := records[i]
+			got := chunk{id: r["objectID"].(string)}
+			got.url, _ = r[tt.config.urlAttribute()].(string)
+			got.heading, _ = r["heading"].(string)
+			got.content, _ = r[tt.config.attribute()].(string)
+			if tt.config.Attribute != "" {
+				got.content, _ = r["content"].(string)
+			}
+			if got != w {
+				t.Errorf("%s: record %d = %+v, want %+v", tt.name, i, got, w)
+			}
+			if r[tt.config.DistinctKey()] != "p" {
+				t.Errorf("%s: record %d has %s %v, want %q", tt.name, i, tt.config.DistinctKey(), r[tt.config.DistinctKey()], "p")
+			}
+		}
+	}
+}
+
+func TestSplitObjectsWithoutObjectID(t *testing.T) {
+	object := algoliasearch.Object{"content": "<h2>A</h2>a<h2>B</h2>b"}
+	records, err := (&SplitConfig{}).SplitObjects([]algoliasearch.Object{object})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0]["parentID"] != nil {
+		t.Errorf("got %v, want the record left for validation to report", records)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		text    string
+		maxSize int
+		want    []string
+	}{
+		{"short", 0, []string{"short"}},
+		{"short", 10, []string{"short"}},
+		{"one. two. three.", 10, []string{"one. two.", "three."}},
+		{"Really? Yes! Done.", 13, []string{"Really? Yes!", "Done."}},
+		{"word word word", 9, []string{"word word", "word"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"ééé", 3, []string{"é", "é", "é"}},
+	}
+
+	for _, tt := range tests {
+		got := splitText(tt.text, tt.maxSize)
+		if len(got) != len(tt.want) {
+			t.Errorf("splitText(%q, %d) = %q, want %q", tt.text, tt.maxSize, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("splitText(%q, %d) = %q, want %q", tt.text, tt.maxSize, got, tt.want)
+				break
+			}
+		}
+	}
+}
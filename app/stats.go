@@ -0,0 +1,261 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// StatsOptions chooses what the statistics report looks at
+type StatsOptions struct {
+	// Facets are the attributes whose number of distinct values is counted
+	Facets []string
+	// GroupBy are the attributes, such as section or lang, to count records by
+	GroupBy []string
+	// Largest is the number of largest records listed, none when not positive
+	Largest int
+}
+
+// IndexStats describes the shape of a set of records
+type IndexStats struct {
+	Records  int                 `json:"records"`
+	Size     SizeStats           `json:"size"`
+	Coverage []AttributeCoverage `json:"coverage"`
+	Facets   []FacetCardinality  `json:"facets"`
+	Largest  []RecordSize        `json:"largest"`
+	Groups   []RecordGroups      `json:"groups"`
+}
+
+// SizeStats is the distribution of the JSON size of the records, in bytes
+type SizeStats struct {
+	Total  int `json:"total"`
+	Min    int `json:"min"`
+	Median int `json:"median"`
+	P95    int `json:"p95"`
+	Max    int `json:"max"`
+}
+
+// AttributeCoverage is the share of records with a non-empty value for an attribute
+type AttributeCoverage struct {
+	Attribute string  `json:"attribute"`
+	Records   int     `json:"records"`
+	Percent   float64 `json:"percent"`
+}
+
+// FacetCardinality is the number of distinct values of a facet
+type FacetCardinality struct {
+	Facet  string `json:"facet"`
+	Values int    `json:"values"`
+}
+
+// RecordSize is the size of one record
+type RecordSize struct {
+	ObjectID string `json:"objectID"`
+	URL      string `json:"url,omitempty"`
+	Bytes    int    `json:"bytes"`
+}
+
+// RecordGroups counts the records by the values of an attribute
+type RecordGroups struct {
+	Attribute string       `json:"attribute"`
+	Counts    []GroupCount `json:"counts"`
+}
+
+// GroupCount is the number of records with one value of an attribute
+type GroupCount struct {
+	Value   string `json:"value"`
+	Records int    `json:"records"`
+}
+
+// noGroupValue stands for records without a value for the attribute
+const noGroupValue = "(none)"
+
+// ComputeStats describes the records. Groups of attributes that no record
+// has are left out.
+func ComputeStats(objects []algoliasearch.Object, opts StatsOptions) (IndexStats, error) {
+	stats := IndexStats{Records: len(objects)}
+
+	sizes := make([]RecordSize, len(objects))
+	attributes := map[string]int{}
+	facets := make([]map[string]bool, len(opts.Facets))
+	for i := range facets {
+		facets[i] = map[string]bool{}
+	}
+	groups := make([]map[string]int, len(opts.GroupBy))
+	for i := range groups {
+		groups[i] = map[string]int{}
+	}
+
+	for i, o := range objects {
+		b, err := json.Marshal(o)
+		if err != nil {
+			return stats, fmt.Errorf("record %d: %s", i, err)
+		}
+		id, _ := o["objectID"].(string)
+		sizes[i] = RecordSize{ObjectID: id, URL: ObjectURL(o), Bytes: len(b)}
+		stats.Size.Total += len(b)
+
+		for attr, v := range o {
+			if !isBlank(v) {
+				attributes[attr]++
+			}
+		}
+		for j, facet := range opts.Facets {
+			for _, v := range facetValues(getAttribute(o, facet)) {
+				facets[j][v] = true
+			}
+		}
+		for j, attr := range opts.GroupBy {
+			values := facetValues(getAttribute(o, attr))
+			if len(values) == 0 {
+				values = []string{noGroupValue}
+			}
+			for _, v := range values {
+				groups[j][v]++
+			}
+		}
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].Bytes > sizes[j].Bytes })
+	if n := len(sizes); n > 0 {
+		stats.Size.Max = sizes[0].Bytes
+		stats.Size.Min = sizes[n-1].Bytes
+		// Percentiles by the nearest rank, counted from the smallest record
+		stats.Size.Median = sizes[n-percentileRank(n, 0.5)].Bytes
+		stats.Size.P95 = sizes[n-percentileRank(n, 0.95)].Bytes
+	}
+	if opts.Largest <= 0 {
+		stats.Largest = sizes[:0]
+	} else if opts.Largest < len(sizes) {
+		stats.Largest = sizes[:opts.Largest]
+	} else {
+		stats.Largest = sizes
+	}
+
+	for attr, n := range attributes {
+		stats.Coverage = append(stats.Coverage, AttributeCoverage{
+			Attribute: attr,
+			Records:   n,
+			Percent:   100 * float64(n) / float64(len(objects)),
+		})
+	}
+	sort.Slice(stats.Coverage, func(i, j int) bool {
+		a, b := stats.Coverage[i], stats.Coverage[j]
+		if a.Records != b.Records {
+			return a.Records > b.Records
+		}
+		return a.Attribute < b.Attribute
+	})
+
+	for i, facet := range opts.Facets {
+		stats.Facets = append(stats.Facets, FacetCardinality{Facet: facet, Values: len(facets[i])})
+	}
+
+	for i, attr := range opts.GroupBy {
+		if groups[i][noGroupValue] == len(objects) {
+			continue
+		}
+		g := RecordGroups{Attribute: attr}
+		for v, n := range groups[i] {
+			g.Counts = append(g.Counts, GroupCount{Value: v, Records: n})
+		}
+		sort.Slice(g.Counts, func(a, b int) bool {
+			if g.Counts[a].Records != g.Counts[b].Records {
+				return g.Counts[a].Records > g.Counts[b].Records
+			}
+			return g.Counts[a].Value < g.Counts[b].Value
+		})
+		stats.Groups = append(stats.Groups, g)
+	}
+
+	return stats, nil
+}
+
+// percentileRank is the 1-based rank of the p percentile of n sorted values
+func percentileRank(n int, p float64) int {
+	rank := int(math.Ceil(p * float64(n)))
+	if rank < 1 {
+		return 1
+	}
+	return rank
+}
+
+// facetValues returns the values of an attribute as Algolia facets them,
+// with every item of a list being a value of its own
+func facetValues(v interface{}) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, facetValues(item)...)
+		}
+		return values
+	case []string:
+		var values []string
+		for _, item := range v {
+			values = append(values, facetValues(item)...)
+		}
+		return values
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		return []string{v}
+	case map[string]interface{}:
+		return nil
+	}
+	return []string{toString(v)}
+}
+
+// FacetAttributes returns the attributes of attributesForFaceting settings,
+// without their searchable() or filterOnly() modifiers
+func FacetAttributes(settings algoliasearch.Map) []string {
+	var facets []string
+	for _, attr := range stringList(settings["attributesForFaceting"]) {
+		for _, modifier := range []string{"searchable(", "filterOnly(", "afterDistinct("} {
+			if strings.HasPrefix(attr, modifier) && strings.HasSuffix(attr, ")") {
+				attr = strings.TrimSuffix(strings.TrimPrefix(attr, modifier), ")")
+			}
+		}
+		facets = append(facets, attr)
+	}
+	return facets
+}
+
+// Stats describes the records of the configured index
+func (c *Config) Stats(opts StatsOptions) (IndexStats, error) {
+	index := c.GetIndex()
+	if opts.Facets == nil {
+		settings, err := c.GetSettings()
+		if err != nil {
+			return IndexStats{}, err
+		}
+		opts.Facets = FacetAttributes(settings)
+	}
+
+	objects, err := BrowseObjects(index)
+	if err != nil {
+		return IndexStats{}, err
+	}
+	return ComputeStats(objects, opts)
+}
+
+// LocalStats describes the records that would be uploaded, before they are
+// sent to Algolia
+func (c *Config) LocalStats(opts StatsOptions) (IndexStats, error) {
+	if opts.Facets == nil {
+		opts.Facets = FacetAttributes(c.IndexSettings())
+	}
+
+	objects, err := c.LoadObjects()
+	if err != nil {
+		return IndexStats{}, err
+	}
+	return ComputeStats(objects, opts)
+}
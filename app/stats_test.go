@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestComputeStatsLargest(t *testing.T) {
+	objects := []algoliasearch.Object{
+		{"objectID": "a", "content": "x"},
+		{"objectID": "b", "content": "xxx"},
+		{"objectID": "c", "content": "xx"},
+	}
+
+	tests := []struct {
+		largest int
+		want    []string
+	}{
+		{-1, []string{}},
+		{0, []string{}},
+		{2, []string{"b", "c"}},
+		{5, []string{"b", "c", "a"}},
+	}
+	for _, tt := range tests {
+		stats, err := ComputeStats(objects, StatsOptions{Largest: tt.largest})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.Largest) != len(tt.want) {
+			t.Errorf("largest %d: got %v, want %v", tt.largest, stats.Largest, tt.want)
+			continue
+		}
+		for i, id := range tt.want {
+			if stats.Largest[i].ObjectID != id {
+				t.Errorf("largest %d: got %v, want %v", tt.largest, stats.Largest, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+)
+
+// batchSize is the number of operations sent to Algolia in a single batch request
+const batchSize = 1000
+
+// SyncResult counts what happened to each record during a sync
+type SyncResult struct {
+	Created   int
+	Updated   int
+	Deleted   int
+	Unchanged int
+}
+
+// HashObject returns a content hash of a search object, ignoring attributes
+// that Algolia adds to records it returns.
+func HashObject(object algoliasearch.Object) (string, error) {
+	clean := algoliasearch.Object{}
+	for k, v := range object {
+		if !isResponseAttribute(k) {
+			clean[k] = v
+		}
+	}
+
+	// encoding/json sorts map keys, so equal objects always hash the same
+	b, err := json.Marshal(clean)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isResponseAttribute reports whether an attribute is added by Algolia to the
+// records it returns rather than being part of the record itself
+func isResponseAttribute(name string) bool {
+	switch name {
+	case "_highlightResult", "_snippetResult", "_rankingInfo":
+		return true
+	}
+	return false
+}
+
+// PlanSync compares the local objects against the remote ones by objectID and
+// content hash, and returns the batch operations needed to make the remote
+// side match the local side.
+func PlanSync(local, remote []algoliasearch.Object) ([]algoliasearch.BatchOperation, SyncResult, error) {
+	var result SyncResult
+	var operations []algoliasearch.BatchOperation
+
+	remoteHashes := make(map[string]string, len(remote))
+	for _, o := range remote {
+		id, err := o.ObjectID()
+		if err != nil {
+			return nil, result, err
+		}
+		if remoteHashes[id], err = HashObject(o); err != nil {
+			return nil, result, err
+		}
+	}
+
+	seen := make(map[string]bool, len(local))
+	for i, o := range local {
+		id, err := o.ObjectID()
+		if err != nil {
+			return nil, result, fmt.Errorf("object %d: %s", i, err)
+		}
+		if seen[id] {
+			return nil, result, fmt.Errorf("object %d: duplicate objectID %q", i, id)
+		}
+		seen[id] = true
+
+		hash, err := HashObject(o)
+		if err != nil {
+			return nil, result, err
+		}
+
+		remoteHash, exists := remoteHashes[id]
+		switch {
+		case !exists:
+			result.Created++
+		case remoteHash != hash:
+			result.Updated++
+		default:
+			result.Unchanged++
+			continue
+		}
+		operations = append(operations, algoliasearch.BatchOperation{Action: "updateObject", Body: o})
+	}
+
+	for id := range remoteHashes {
+		if !seen[id] {
+			result.Deleted++
+			operations = append(operations, algoliasearch.BatchOperation{
+				Action: "deleteObject",
+				Body:   algoliasearch.Object{"objectID": id},
+			})
+		}
+	}
+
+	return operations, result, nil
+}
+
+// SyncIndex sends only the changes needed to make the index match the given objects
+func SyncIndex(index algoliasearch.Index, objects []algoliasearch.Object) (SyncResult, error) {
+	log.Info("Browsing existing objects")
+	remote, err := BrowseObjects(index)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	operations, result, err := PlanSync(objects, remote)
+	if err != nil {
+		return result, err
+	}
+
+	log.WithField("operations", len(operations)).Info("Sending changes")
+	for start := 0; start < len(operations); start += batchSize {
+		end := start + batchSize
+		if end > len(operations) {
+			end = len(operations)
+		}
+		if _, err = index.Batch(operations[start:end]); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
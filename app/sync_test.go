@@ -0,0 +1,102 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestPlanSync(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote []algoliasearch.Object
+		want          SyncResult
+		ops           []string
+	}{
+		{
+			"empty",
+			nil, nil,
+			SyncResult{},
+			nil,
+		},
+		{
+			"new index",
+			[]algoliasearch.Object{{"objectID": "a"}, {"objectID": "b"}},
+			nil,
+			SyncResult{Created: 2},
+			[]string{"updateObject a", "updateObject b"},
+		},
+		{
+			"unchanged, ignoring the attributes Algolia adds",
+			[]algoliasearch.Object{{"objectID": "a", "title": "A", "n": 1}},
+			[]algoliasearch.Object{{"objectID": "a", "title": "A", "n": float64(1), "_highlightResult": map[string]interface{}{}}},
+			SyncResult{Unchanged: 1},
+			nil,
+		},
+		{
+			"created, updated, deleted and unchanged",
+			[]algoliasearch.Object{
+				{"objectID": "keep", "title": "K"},
+				{"objectID": "change", "title": "new"},
+				{"objectID": "add", "title": "A"},
+			},
+			[]algoliasearch.Object{
+				{"objectID": "keep", "title": "K"},
+				{"objectID": "change", "title": "old"},
+				{"objectID": "gone", "title": "G"},
+				{"objectID": "gone too"},
+			},
+			SyncResult{Created: 1, Updated: 1, Deleted: 2, Unchanged: 1},
+			[]string{"deleteObject gone", "deleteObject gone too", "updateObject add", "updateObject change"},
+		},
+		{
+			"removed attribute",
+			[]algoliasearch.Object{{"objectID": "a"}},
+			[]algoliasearch.Object{{"objectID": "a", "draft": true}},
+			SyncResult{Updated: 1},
+			[]string{"updateObject a"},
+		},
+	}
+
+	for _, tt := range tests {
+		operations, result, err := PlanSync(tt.local, tt.remote)
+		if err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if result != tt.want {
+			t.Errorf("%s: result = %+v, want %+v", tt.name, result, tt.want)
+		}
+
+		var ops []string
+		for _, op := range operations {
+			id, _ := op.Body.(algoliasearch.Object).ObjectID()
+			ops = append(ops, op.Action+" "+id)
+		}
+		sort.Strings(ops)
+		if strings.Join(ops, ", ") != strings.Join(tt.ops, ", ") {
+			t.Errorf("%s: operations = %v, want %v", tt.name, ops, tt.ops)
+		}
+	}
+}
+
+func TestPlanSyncErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote []algoliasearch.Object
+		err           string
+	}{
+		{"local without objectID", []algoliasearch.Object{{"objectID": "a"}, {"title": "T"}}, nil, "object 1"},
+		{"duplicate local objectID", []algoliasearch.Object{{"objectID": "a"}, {"objectID": "a"}}, nil, `object 1: duplicate objectID "a"`},
+		{"remote without objectID", nil, []algoliasearch.Object{{"title": "T"}}, "objectID"},
+	}
+
+	for _, tt := range tests {
+		_, _, err := PlanSync(tt.local, tt.remote)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
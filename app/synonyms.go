@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// Synonym types supported by Algolia
+const (
+	SynonymRegular     = "synonym"
+	SynonymOneWay      = "oneWaySynonym"
+	SynonymPlaceholder = "placeholder"
+)
+
+// synonymsPerPage is the page size used when retrieving synonyms
+const synonymsPerPage = 1000
+
+// GetSynonyms retrieves every synonym of the index, page by page
+func GetSynonyms(index algoliasearch.Index) ([]algoliasearch.Synonym, error) {
+	var synonyms []algoliasearch.Synonym
+	for page := 0; ; page++ {
+		hits, err := index.SearchSynonyms("", nil, page, synonymsPerPage)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range hits {
+			s.HighlightResult = nil
+			synonyms = append(synonyms, s)
+		}
+
+		if len(hits) < synonymsPerPage {
+			return synonyms, nil
+		}
+	}
+}
+
+// LoadSynonymsFile reads a list of synonyms from a YAML or JSON file and checks each of them
+func LoadSynonymsFile(file string) ([]algoliasearch.Synonym, error) {
+	var synonyms []algoliasearch.Synonym
+	if err := ReadDataFile(file, &synonyms); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(synonyms))
+	for i, s := range synonyms {
+		if err := ValidateSynonym(s); err != nil {
+			return nil, fmt.Errorf("synonym %d: %s", i, err)
+		}
+		if seen[s.ObjectID] {
+			return nil, fmt.Errorf("synonym %d: duplicate objectID %q", i, s.ObjectID)
+		}
+		seen[s.ObjectID] = true
+	}
+	return synonyms, nil
+}
+
+// ValidateSynonym checks that a synonym has an objectID, a known type and the fields its type requires
+func ValidateSynonym(s algoliasearch.Synonym) error {
+	if s.ObjectID == "" {
+		return fmt.Errorf("missing objectID")
+	}
+
+	switch s.Type {
+	case SynonymRegular:
+		if len(s.Synonyms) < 2 {
+			return fmt.Errorf("%s: a regular synonym needs at least two synonyms", s.ObjectID)
+		}
+	case SynonymOneWay:
+		if s.Input == "" || len(s.Synonyms) == 0 {
+			return fmt.Errorf("%s: a one-way synonym needs an input and synonyms", s.ObjectID)
+		}
+	case algoliasearch.AltCorrection1, algoliasearch.AltCorrection2:
+		if s.Word == "" || len(s.Corrections) == 0 {
+			return fmt.Errorf("%s: an alternative correction needs a word and corrections", s.ObjectID)
+		}
+	case SynonymPlaceholder:
+		if s.Placeholder == "" || len(s.Replacements) == 0 {
+			return fmt.Errorf("%s: a placeholder needs a placeholder and replacements", s.ObjectID)
+		}
+	default:
+		return fmt.Errorf("%s: unknown synonym type %q", s.ObjectID, s.Type)
+	}
+	return nil
+}
+
+// ReplaceSynonyms replaces all the synonyms of the index with the given ones and waits until they are live
+func ReplaceSynonyms(index algoliasearch.Index, synonyms []algoliasearch.Synonym, forwardToReplicas bool) error {
+	res, err := index.BatchSynonyms(synonyms, true, forwardToReplicas)
+	if err != nil {
+		return err
+	}
+	return index.WaitTask(res.TaskID)
+}
+
+// DeleteSynonym deletes a single synonym from the index and waits until it is gone
+func DeleteSynonym(index algoliasearch.Index, objectID string, forwardToReplicas bool) error {
+	res, err := index.DeleteSynonym(objectID, forwardToReplicas)
+	if err != nil {
+		return err
+	}
+	return index.WaitTask(res.TaskID)
+}
+
+// FormatSynonym renders a synonym as a single line of text
+func FormatSynonym(s algoliasearch.Synonym) string {
+	switch s.Type {
+	case SynonymRegular:
+		return strings.Join(s.Synonyms, " = ")
+	case SynonymOneWay:
+		return fmt.Sprintf("%s -> %s", s.Input, strings.Join(s.Synonyms, ", "))
+	case algoliasearch.AltCorrection1, algoliasearch.AltCorrection2:
+		return fmt.Sprintf("%s -> %s", s.Word, strings.Join(s.Corrections, ", "))
+	case SynonymPlaceholder:
+		return fmt.Sprintf("%s -> %s", s.Placeholder, strings.Join(s.Replacements, ", "))
+	}
+	return ""
+}
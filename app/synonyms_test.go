@@ -0,0 +1,172 @@
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// tempDataFile writes contents to a file with the given name in a new
+// directory and returns its path along with a function removing it
+func tempDataFile(t *testing.T, name, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestValidateSynonym(t *testing.T) {
+	tests := []struct {
+		synonym algoliasearch.Synonym
+		err     string
+	}{
+		{algoliasearch.Synonym{ObjectID: "a", Type: SynonymRegular, Synonyms: []string{"go", "golang"}}, ""},
+		{algoliasearch.Synonym{ObjectID: "a", Type: SynonymOneWay, Input: "go", Synonyms: []string{"golang"}}, ""},
+		{algoliasearch.Synonym{ObjectID: "a", Type: algoliasearch.AltCorrection1, Word: "hugo", Corrections: []string{"hogu"}}, ""},
+		{algoliasearch.Synonym{ObjectID: "a", Type: algoliasearch.AltCorrection2, Word: "hugo", Corrections: []string{"hgo"}}, ""},
+		{algoliasearch.Synonym{ObjectID: "a", Type: SynonymPlaceholder, Placeholder: "<v>", Replacements: []string{"1", "2"}}, ""},
+		{algoliasearch.Synonym{Type: SynonymRegular, Synonyms: []string{"go", "golang"}}, "missing objectID"},
+		{algoliasearch.Synonym{ObjectID: "a", Type: SynonymRegular, Synonyms: []string{"go"}}, "a: a regular synonym needs at least two synonyms"},
+		{algoliasearch.Synonym{ObjectID: "a", Type: SynonymOneWay, Synonyms: []string{"golang"}}, "a: a one-way synonym needs an input and synonyms"},
+		{algoliasearch.Synonym{ObjectID: "a", Type: algoliasearch.AltCorrection1, Word: "hugo"}, "a: an alternative correction needs a word and corrections"},
+		{algoliasearch.Synonym{ObjectID: "a", Type: SynonymPlaceholder, Placeholder: "<v>"}, "a: a placeholder needs a placeholder and replacements"},
+		{algoliasearch.Synonym{ObjectID: "a", Type: "twoWay"}, `a: unknown synonym type "twoWay"`},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSynonym(tt.synonym)
+		if got := fmt.Sprint(err); (tt.err == "" && err != nil) || (tt.err != "" && got != tt.err) {
+			t.Errorf("ValidateSynonym(%+v) = %v, want %q", tt.synonym, err, tt.err)
+		}
+	}
+}
+
+func TestLoadSynonymsFile(t *testing.T) {
+	file, cleanup := tempDataFile(t, "synonyms.yaml", `
+- objectID: go
+  type: synonym
+  synonyms: [go, golang]
+- objectID: docs
+  type: oneWaySynonym
+  input: docs
+  synonyms: [documentation]
+`)
+	defer cleanup()
+
+	synonyms, err := LoadSynonymsFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []algoliasearch.Synonym{
+		{ObjectID: "go", Type: SynonymRegular, Synonyms: []string{"go", "golang"}},
+		{ObjectID: "docs", Type: SynonymOneWay, Input: "docs", Synonyms: []string{"documentation"}},
+	}
+	if !reflect.DeepEqual(synonyms, want) {
+		t.Errorf("LoadSynonymsFile = %+v, want %+v", synonyms, want)
+	}
+}
+
+func TestLoadSynonymsFileErrors(t *testing.T) {
+	tests := []struct {
+		contents string
+		err      string
+	}{
+		{`[{"objectID": "go", "type": "synonym", "synonyms": ["go"]}]`, "synonym 0: go: a regular synonym needs"},
+		{`[{"objectID": "go", "type": "synonym", "synonyms": ["go", "golang"]},
+		   {"objectID": "go", "type": "synonym", "synonyms": ["go", "gopher"]}]`, `synonym 1: duplicate objectID "go"`},
+		{`{"objectID": "go"}`, "cannot unmarshal"},
+	}
+
+	for _, tt := range tests {
+		file, cleanup := tempDataFile(t, "synonyms.json", tt.contents)
+		if _, err := LoadSynonymsFile(file); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("LoadSynonymsFile(%s): error = %v, want %q", tt.contents, err, tt.err)
+		}
+		cleanup()
+	}
+}
+
+func TestGetSynonyms(t *testing.T) {
+	index := &fakeIndex{name: "docs"}
+	newFakeClient(index)
+	for n := 0; n < synonymsPerPage+1; n++ {
+		index.synonyms = append(index.synonyms, algoliasearch.Synonym{
+			ObjectID:        fmt.Sprint(n),
+			Type:            SynonymRegular,
+			Synonyms:        []string{"a", "b"},
+			HighlightResult: algoliasearch.Map{"synonyms": "<em>a</em>"},
+		})
+	}
+
+	synonyms, err := GetSynonyms(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(synonyms) != synonymsPerPage+1 {
+		t.Fatalf("GetSynonyms returned %d synonyms, want %d", len(synonyms), synonymsPerPage+1)
+	}
+	for _, s := range synonyms {
+		if s.HighlightResult != nil {
+			t.Fatalf("synonym %s kept its highlighting", s.ObjectID)
+		}
+	}
+	if want := []string{"searchSynonyms docs 0", "searchSynonyms docs 1"}; !reflect.DeepEqual(index.client.calls, want) {
+		t.Errorf("calls = %v, want %v", index.client.calls, want)
+	}
+}
+
+func TestReplaceAndDeleteSynonyms(t *testing.T) {
+	index := &fakeIndex{name: "docs", synonyms: []algoliasearch.Synonym{{ObjectID: "old"}}}
+	client := newFakeClient(index)
+
+	synonyms := []algoliasearch.Synonym{{ObjectID: "a"}, {ObjectID: "b"}}
+	if err := ReplaceSynonyms(index, synonyms, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteSynonym(index, "a", false); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index.synonyms, []algoliasearch.Synonym{{ObjectID: "b"}}) {
+		t.Errorf("synonyms = %+v, want only b", index.synonyms)
+	}
+	want := []string{"batchSynonyms docs replace=true forward=true", "deleteSynonym docs a forward=false"}
+	if !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("calls = %v, want %v", client.calls, want)
+	}
+
+	client.fail = []string{"batchSynonyms"}
+	if err := ReplaceSynonyms(index, synonyms, false); err == nil {
+		t.Errorf("ReplaceSynonyms did not return the error of the batch")
+	}
+}
+
+func TestFormatSynonym(t *testing.T) {
+	tests := []struct {
+		synonym algoliasearch.Synonym
+		want    string
+	}{
+		{algoliasearch.Synonym{Type: SynonymRegular, Synonyms: []string{"go", "golang"}}, "go = golang"},
+		{algoliasearch.Synonym{Type: SynonymOneWay, Input: "docs", Synonyms: []string{"guide", "manual"}}, "docs -> guide, manual"},
+		{algoliasearch.Synonym{Type: algoliasearch.AltCorrection1, Word: "hugo", Corrections: []string{"hogu"}}, "hugo -> hogu"},
+		{algoliasearch.Synonym{Type: SynonymPlaceholder, Placeholder: "<v>", Replacements: []string{"1", "2"}}, "<v> -> 1, 2"},
+		{algoliasearch.Synonym{Type: "unknown"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSynonym(tt.synonym); got != tt.want {
+			t.Errorf("FormatSynonym(%+v) = %q, want %q", tt.synonym, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+var (
+	htmlComment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlScript    = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)>`)
+	htmlTag       = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlPre       = regexp.MustCompile(`(?is)<pre\b.*?</pre\s*>`)
+	hugoShortcode = regexp.MustCompile(`(?s)\{\{[<%].*?[%>]\}\}`)
+	hugoHighlight = regexp.MustCompile(`(?s)\{\{[<%]\s*highlight\b.*?[%>]\}\}.*?\{\{[<%]\s*/\s*highlight\s*[%>]\}\}`)
+	hugoDelimiter = regexp.MustCompile(`\{\{[<%]|[%>]\}\}`)
+	whitespace    = regexp.MustCompile(`[\s\p{Zs}]+`)
+)
+
+// TextOptions selects the optional steps of NormalizeText
+type TextOptions struct {
+	// DropCode removes code blocks instead of keeping their text
+	DropCode bool `mapstructure:"drop_code"`
+	// Markdown strips Markdown syntax as well as HTML
+	Markdown bool `mapstructure:"markdown"`
+}
+
+// NormalizeConfig selects the attributes that NormalizeText is applied to
+type NormalizeConfig struct {
+	Attributes  []string `mapstructure:"attributes"`
+	TextOptions `mapstructure:",squash"`
+}
+
+// HTMLToText strips tags from an HTML fragment, decodes its entities and
+// collapses the remaining whitespace
+func HTMLToText(s string) string {
+	s = htmlComment.ReplaceAllString(s, " ")
+	s = htmlScript.ReplaceAllString(s, " ")
+	s = htmlTag.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return CollapseWhitespace(s)
+}
+
+// NormalizeText turns rendered or raw page content into clean plain text. It
+// removes Hugo shortcodes, including any left escaped in the HTML, strips tags,
+// decodes entities and collapses whitespace.
+func NormalizeText(s string, opts TextOptions) string {
+	if opts.DropCode {
+		s = DropCode(s)
+	}
+	if opts.Markdown {
+		s = MarkdownToText(s)
+	}
+
+	s = StripShortcodes(s)
+	s = HTMLToText(s)
+	// Shortcodes inside code blocks are rendered with their brackets escaped
+	s = StripShortcodes(s)
+	s = hugoDelimiter.ReplaceAllString(s, " ")
+	return CollapseWhitespace(s)
+}
+
+// DropCode removes HTML <pre> blocks, Markdown code fences and Hugo highlight shortcodes
+func DropCode(s string) string {
+	s = htmlPre.ReplaceAllString(s, " ")
+	s = mdCodeFence.ReplaceAllString(s, " ")
+	return hugoHighlight.ReplaceAllString(s, " ")
+}
+
+// StripShortcodes removes Hugo shortcode calls such as {{< figure >}} and {{% note %}}
+func StripShortcodes(s string) string {
+	return hugoShortcode.ReplaceAllString(s, " ")
+}
+
+// CollapseWhitespace replaces every run of whitespace, including non-breaking
+// spaces, with a single space
+func CollapseWhitespace(s string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(s, " "))
+}
+
+// NormalizeObjects applies NormalizeText to the configured attributes of every
+// record in place. Lists of strings are normalized item by item.
+func NormalizeObjects(configs []NormalizeConfig, objects []algoliasearch.Object) {
+	for _, o := range objects {
+		for _, c := range configs {
+			normalize := func(s string) string { return NormalizeText(s, c.TextOptions) }
+			for _, attr := range c.Attributes {
+				if v, ok := o[attr]; ok {
+					o[attr] = mapStrings(v, normalize)
+				}
+			}
+		}
+	}
+}
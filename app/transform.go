@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// Transform is one step of the pipeline applied to every record before upload
+type Transform struct {
+	// Type is one of rename, remove, set, truncate, lowercase, strip_html or default
+	Type string `mapstructure:"type"`
+	// Attribute is the attribute the step works on
+	Attribute string `mapstructure:"attribute"`
+	// To is the new name of the attribute for rename
+	To string `mapstructure:"to"`
+	// Value is the value used by set and default
+	Value interface{} `mapstructure:"value"`
+	// Length is the largest number of characters kept by truncate
+	Length int `mapstructure:"length"`
+}
+
+// Transforms is an ordered list of transform steps
+type Transforms []Transform
+
+// Check reports the first step that is missing something it needs
+func (ts Transforms) Check() error {
+	for i, t := range ts {
+		if t.Attribute == "" {
+			return fmt.Errorf("transform %d (%s): missing attribute", i, t.Type)
+		}
+
+		switch t.Type {
+		case "rename":
+			if t.To == "" {
+				return fmt.Errorf("transform %d (rename): missing to", i)
+			}
+		case "truncate":
+			if t.Length <= 0 {
+				return fmt.Errorf("transform %d (truncate): length must be positive", i)
+			}
+		case "set", "default":
+			if t.Value == nil {
+				return fmt.Errorf("transform %d (%s): missing value", i, t.Type)
+			}
+		case "remove", "lowercase", "strip_html":
+		default:
+			return fmt.Errorf("transform %d: unknown type %q", i, t.Type)
+		}
+	}
+	return nil
+}
+
+// Apply runs every step, in order, on every record
+func (ts Transforms) Apply(objects []algoliasearch.Object) error {
+	if err := ts.Check(); err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		for _, t := range ts {
+			t.apply(o)
+		}
+	}
+	return nil
+}
+
+// apply runs a single step on a record. Steps that work on strings leave
+// other values alone.
+func (t Transform) apply(o algoliasearch.Object) {
+	v, exists := o[t.Attribute]
+
+	switch t.Type {
+	case "rename":
+		if exists {
+			delete(o, t.Attribute)
+			o[t.To] = v
+		}
+	case "remove":
+		delete(o, t.Attribute)
+	case "set":
+		o[t.Attribute] = jsonValue(t.Value)
+	case "default":
+		if isBlank(v) {
+			o[t.Attribute] = jsonValue(t.Value)
+		}
+	case "truncate":
+		if s, ok := v.(string); ok {
+			o[t.Attribute] = truncate(s, t.Length)
+		}
+	case "lowercase":
+		if exists {
+			o[t.Attribute] = mapStrings(v, strings.ToLower)
+		}
+	case "strip_html":
+		if exists {
+			o[t.Attribute] = mapStrings(v, HTMLToText)
+		}
+	}
+}
+
+// mapStrings applies f to a string, or to every string in a list
+func mapStrings(v interface{}, f func(string) string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return f(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = mapStrings(item, f)
+		}
+		return out
+	case []string:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = f(item)
+		}
+		return out
+	}
+	return v
+}
+
+// truncate shortens s to at most n characters, cutting at a word boundary when
+// there is one in the second half of the kept text
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	runes := []rune(s)[:n]
+	cut := string(runes)
+	if i := strings.LastIndexAny(cut, " \t\n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
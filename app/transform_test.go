@@ -0,0 +1,95 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestTransformsApply(t *testing.T) {
+	tests := []struct {
+		name string
+		step Transform
+		in   algoliasearch.Object
+		want algoliasearch.Object
+	}{
+		{
+			"rename",
+			Transform{Type: "rename", Attribute: "summary", To: "description"},
+			algoliasearch.Object{"summary": "s"},
+			algoliasearch.Object{"description": "s"},
+		},
+		{
+			"rename missing",
+			Transform{Type: "rename", Attribute: "summary", To: "description"},
+			algoliasearch.Object{},
+			algoliasearch.Object{},
+		},
+		{
+			"remove",
+			Transform{Type: "remove", Attribute: "draft"},
+			algoliasearch.Object{"draft": true, "title": "t"},
+			algoliasearch.Object{"title": "t"},
+		},
+		{
+			"set",
+			Transform{Type: "set", Attribute: "site", Value: "docs"},
+			algoliasearch.Object{},
+			algoliasearch.Object{"site": "docs"},
+		},
+		{
+			"default blank",
+			Transform{Type: "default", Attribute: "section", Value: "general"},
+			algoliasearch.Object{"section": ""},
+			algoliasearch.Object{"section": "general"},
+		},
+		{
+			"default present",
+			Transform{Type: "default", Attribute: "section", Value: "general"},
+			algoliasearch.Object{"section": "docs"},
+			algoliasearch.Object{"section": "docs"},
+		},
+		{
+			"truncate",
+			Transform{Type: "truncate", Attribute: "description", Length: 12},
+			algoliasearch.Object{"description": "one two three four"},
+			algoliasearch.Object{"description": "one two"},
+		},
+		{
+			"lowercase list",
+			Transform{Type: "lowercase", Attribute: "tags"},
+			algoliasearch.Object{"tags": []interface{}{"Go", "Hugo", float64(1)}},
+			algoliasearch.Object{"tags": []interface{}{"go", "hugo", float64(1)}},
+		},
+		{
+			"lowercase missing",
+			Transform{Type: "lowercase", Attribute: "tags"},
+			algoliasearch.Object{"title": "T"},
+			algoliasearch.Object{"title": "T"},
+		},
+		{
+			"strip_html",
+			Transform{Type: "strip_html", Attribute: "description"},
+			algoliasearch.Object{"description": "<p>Fish &amp; chips</p>"},
+			algoliasearch.Object{"description": "Fish & chips"},
+		},
+		{
+			"strip_html missing",
+			Transform{Type: "strip_html", Attribute: "description"},
+			algoliasearch.Object{"title": "T"},
+			algoliasearch.Object{"title": "T"},
+		},
+	}
+
+	for _, tt := range tests {
+		objects := []algoliasearch.Object{tt.in}
+		if err := (Transforms{tt.step}).Apply(objects); err != nil {
+			t.Errorf("%s: %s", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(objects[0], tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, objects[0], tt.want)
+		}
+	}
+}
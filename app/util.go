@@ -11,7 +11,7 @@ import (
 
 func init() {
 	log.SetHandler(cli.Default)
-	log.SetLevel(log.DebugLevel)
+	log.SetLevel(log.InfoLevel)
 }
 
 // LoadObjectFile loads a JSON file of search terms and returns a slice of algoliasearch.Objects
@@ -36,3 +36,39 @@ func ClearIndex(index algoliasearch.Index) error {
 	_, err := index.Clear()
 	return err
 }
+
+// BrowseObjects retrieves every record stored in the search index
+func BrowseObjects(index algoliasearch.Index) ([]algoliasearch.Object, error) {
+	var objects []algoliasearch.Object
+
+	it, err := index.BrowseAll(algoliasearch.Map{})
+	for err == nil {
+		var hit algoliasearch.Map
+		if hit, err = it.Next(); err == nil {
+			objects = append(objects, algoliasearch.Object(hit))
+		}
+	}
+
+	// An empty index or the end of the last page are both reported this way
+	if err != algoliasearch.NoMoreHitsErr {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// QueryParams converts decoded JSON or YAML values into the Go types the
+// Algolia client expects for query parameters
+func QueryParams(raw map[string]interface{}) algoliasearch.Map {
+	params := algoliasearch.Map{}
+	for k, v := range raw {
+		switch k {
+		case "numericFilters", "tagFilters":
+			// These are checked as []interface{}, so keep lists as they are
+			params[k] = v
+		default:
+			params[k] = settingValue(v)
+		}
+	}
+	return params
+}
@@ -0,0 +1,243 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+// defaultMaxRecordSize is the largest record Algolia accepts on any plan, in bytes
+const defaultMaxRecordSize = 100000
+
+// urlAttributes are the attributes looked at, in order, to find the URL of a record
+var urlAttributes = []string{"permalink", "url", "uri", "relpermalink", "href"}
+
+// ValidationConfig configures the checks run on records before they are uploaded
+type ValidationConfig struct {
+	// MaxRecordSize is the largest record in bytes, 100000 by default
+	MaxRecordSize int `mapstructure:"max_record_size"`
+	// MaxRecords is the largest number of records, or 0 for no limit
+	MaxRecords int `mapstructure:"max_records"`
+	// RequiredAttributes must be present and not empty on every record
+	RequiredAttributes []string `mapstructure:"required_attributes"`
+}
+
+// ValidationError is returned when records fail validation before an upload
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d validation errors", len(e.Violations))
+}
+
+// Violation is a record that failed a validation check. Index is the position
+// of the record in the upload file, or of the page it was built from, and is
+// -1 for problems with the records as a whole or records added by plugins.
+type Violation struct {
+	Index    int    `json:"index"`
+	ObjectID string `json:"objectID,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+}
+
+func (v Violation) String() string {
+	location := "records"
+	switch {
+	case v.Index >= 0:
+		location = fmt.Sprintf("record %d", v.Index)
+	case v.ObjectID != "":
+		location = fmt.Sprintf("record %q", v.ObjectID)
+	}
+	if v.URL != "" {
+		location += " (" + v.URL + ")"
+	}
+	return fmt.Sprintf("%s: %s: %s", location, v.Check, v.Message)
+}
+
+// ObjectURL returns the URL of a record, looking at the usual URL attributes
+func ObjectURL(object algoliasearch.Object) string {
+	for _, attr := range urlAttributes {
+		if u, ok := object[attr].(string); ok && u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// Validate checks every record and returns all the violations found, in order
+func (v ValidationConfig) Validate(objects []algoliasearch.Object) []Violation {
+	return v.validate(objects, func(i int) int { return i })
+}
+
+// validate checks every record, reporting each at the position returned by
+// source for its index in objects
+func (v ValidationConfig) validate(objects []algoliasearch.Object, source func(int) int) []Violation {
+	maxSize := v.MaxRecordSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxRecordSize
+	}
+
+	var violations []Violation
+	seen := map[string]int{}
+	for i, o := range objects {
+		id, _ := o["objectID"].(string)
+		add := func(check, format string, args ...interface{}) {
+			violations = append(violations, Violation{
+				Index:    source(i),
+				ObjectID: id,
+				URL:      ObjectURL(o),
+				Check:    check,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		switch raw, ok := o["objectID"]; {
+		case !ok:
+			add("objectID", "missing objectID")
+		case id == "":
+			if _, isString := raw.(string); isString {
+				add("objectID", "empty objectID")
+			} else {
+				add("objectID", "objectID must be a string, not %T", raw)
+			}
+		default:
+			if first, dup := seen[id]; dup {
+				add("objectID", "duplicate objectID %q, first used by record %d", id, source(first))
+			} else {
+				seen[id] = i
+			}
+		}
+
+		if b, err := json.Marshal(o); err != nil {
+			add("size", "cannot be encoded as JSON: %s", err)
+		} else if len(b) > maxSize {
+			add("size", "%d bytes is over the limit of %d bytes", len(b), maxSize)
+		}
+
+		for _, attr := range v.RequiredAttributes {
+			if isBlank(o[attr]) {
+				add("required", "missing required attribute %q", attr)
+			}
+		}
+	}
+
+	if v.MaxRecords > 0 && len(objects) > v.MaxRecords {
+		violations = append(violations, Violation{
+			Index:   -1,
+			Check:   "count",
+			Message: fmt.Sprintf("%d records is over the limit of %d", len(objects), v.MaxRecords),
+		})
+	}
+
+	return violations
+}
+
+// isBlank reports whether an attribute value is missing, null, an empty string or an empty list
+func isBlank(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// recordSources traces the records coming out of the pipeline back to the
+// loaded records they were built from, so that violations point at the entry
+// of the upload file or the page to fix rather than at a transformed or split
+// record. Every record carries the position of its loaded record through the
+// stages that keep the records in order; the records that plugins or
+// splitting build anew are traced by objectID.
+type recordSources struct {
+	// index holds the position of the loaded record each record at the
+	// current stage was built from, or -1 when it cannot be traced
+	index []int
+	ids   map[string]int
+	// parent is the attribute holding the objectID of a split record's page
+	parent string
+}
+
+func newRecordSources(n int) *recordSources {
+	s := &recordSources{index: make([]int, n), ids: map[string]int{}}
+	for i := range s.index {
+		s.index[i] = i
+	}
+	return s
+}
+
+// source returns the position of the loaded record the record at position i
+// was built from, or -1
+func (s *recordSources) source(i int) int {
+	if i < 0 || i >= len(s.index) {
+		return -1
+	}
+	return s.index[i]
+}
+
+// keep returns the records at the kept positions, which a filter has chosen
+// in order, and follows them
+func (s *recordSources) keep(objects []algoliasearch.Object, kept []int) []algoliasearch.Object {
+	result := make([]algoliasearch.Object, len(kept))
+	index := make([]int, len(kept))
+	for i, k := range kept {
+		result[i] = objects[k]
+		index[i] = s.source(k)
+	}
+	s.index = index
+	return result
+}
+
+// remember records the current objectID of every record that can be traced,
+// so that the records that plugins or splitting build from it can be too
+func (s *recordSources) remember(objects []algoliasearch.Object) {
+	for i, o := range objects {
+		src := s.source(i)
+		id, ok := o["objectID"].(string)
+		if src < 0 || !ok || id == "" {
+			continue
+		}
+		if _, seen := s.ids[id]; !seen {
+			s.ids[id] = src
+		}
+	}
+}
+
+// retrace finds the loaded records of records built anew, by their objectID
+// or the objectID of the page they were split from
+func (s *recordSources) retrace(objects []algoliasearch.Object) {
+	index := make([]int, len(objects))
+	for i, o := range objects {
+		index[i] = -1
+		if id, ok := o["objectID"].(string); ok {
+			if src, found := s.ids[id]; found {
+				index[i] = src
+				continue
+			}
+		}
+		if id, ok := o[s.parent].(string); ok && s.parent != "" {
+			if src, found := s.ids[id]; found {
+				index[i] = src
+			}
+		}
+	}
+	s.index = index
+}
+
+// locate moves violations found at positions of the current records to the
+// loaded records
+func (s *recordSources) locate(violations []Violation) {
+	for i, v := range violations {
+		if v.Index >= 0 {
+			violations[i].Index = s.source(v.Index)
+		}
+	}
+}
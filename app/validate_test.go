@@ -0,0 +1,149 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestValidate(t *testing.T) {
+	v := ValidationConfig{MaxRecordSize: 60, MaxRecords: 3, RequiredAttributes: []string{"title"}}
+	objects := []algoliasearch.Object{
+		{"objectID": "a", "title": "A"},
+		{"title": "B"},
+		{"objectID": "", "title": "C"},
+		{"objectID": float64(4), "title": "D"},
+		{"objectID": "a", "title": " "},
+		{"objectID": "f", "title": strings.Repeat("F", 60)},
+	}
+
+	want := []struct {
+		index int
+		check string
+	}{
+		{1, "objectID"},
+		{2, "objectID"},
+		{3, "objectID"},
+		{4, "objectID"},
+		{4, "required"},
+		{5, "size"},
+		{-1, "count"},
+	}
+	violations := v.Validate(objects)
+	if len(violations) != len(want) {
+		t.Fatalf("got %d violations, want %d: %v", len(violations), len(want), violations)
+	}
+	for i, w := range want {
+		if violations[i].Index != w.index || violations[i].Check != w.check {
+			t.Errorf("violation %d = %s, want record %d %s", i, violations[i], w.index, w.check)
+		}
+	}
+	if got := violations[len(violations)-1].String(); !strings.HasPrefix(got, "records: count:") {
+		t.Errorf("count violation = %q", got)
+	}
+}
+
+func TestValidateObjectsReportsLoadedRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "index.json")
+	records := `[
+		{"objectID": "one", "title": "One", "content": "<h2>A</h2><p>a</p><h2>B</h2><p>b</p>"},
+		{"objectID": "two", "content": "<h2>A</h2><p>a</p><h2>B</h2><p>b</p>"},
+		{"objectID": "three", "title": "Three", "content": "<p>c</p>"}
+	]`
+	if err = ioutil.WriteFile(file, []byte(records), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		UploadFile: file,
+		Transforms: Transforms{{Type: "rename", Attribute: "objectID", To: "slug"}},
+		Computed:   ComputedAttributes{{Attribute: "objectID", Expression: `"page-" + slug`}},
+		Split:      &SplitConfig{},
+		Validation: ValidationConfig{RequiredAttributes: []string{"title"}},
+	}
+	violations, err := c.ValidateObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 2 {
+		t.Fatalf("got %d violations, want 2: %v", len(violations), violations)
+	}
+	for _, v := range violations {
+		if v.Index != 1 || v.Check != "required" {
+			t.Errorf("got %s, want record 1 required", v)
+		}
+	}
+}
+
+func TestValidateObjectsReportsRecordsKeptByTheSitemap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeSitemaps(t, dir)
+
+	file := filepath.Join(dir, "index.json")
+	records := `[
+		{"objectID": "other", "permalink": "/other/"},
+		{"objectID": "home", "permalink": "/docs/en/", "title": "Home"},
+		{"objectID": "guide", "permalink": "/docs/en/guide/"}
+	]`
+	if err = ioutil.WriteFile(file, []byte(records), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Config{
+		UploadFile: file,
+		SiteDir:    dir,
+		Sitemap:    &SitemapConfig{},
+		Validation: ValidationConfig{RequiredAttributes: []string{"title"}},
+	}
+	violations, err := c.ValidateObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 1 || violations[0].Index != 2 || violations[0].ObjectID != "guide" {
+		t.Errorf("got %v, want record 2 missing its title", violations)
+	}
+}
+
+func TestRecordSources(t *testing.T) {
+	s := newRecordSources(4)
+	objects := []algoliasearch.Object{{"objectID": "a"}, {"objectID": "b"}, {"objectID": "c"}, {"objectID": "d"}}
+
+	objects = s.keep(objects, []int{1, 3})
+	s.remember(objects)
+	// A plugin renames b and adds a record
+	objects = []algoliasearch.Object{{"objectID": "b2"}, {"objectID": "d"}, {"objectID": "new"}}
+	s.retrace(objects)
+	if want := []int{-1, 3, -1}; !reflect.DeepEqual(s.index, want) {
+		t.Errorf("after the plugin, sources = %v, want %v", s.index, want)
+	}
+
+	s.remember(objects)
+	s.parent = "parentID"
+	objects = []algoliasearch.Object{{"objectID": "d#0", "parentID": "d"}, {"objectID": "d#1", "parentID": "d"}, {"objectID": "x#0", "parentID": "x"}}
+	s.retrace(objects)
+
+	violations := []Violation{{Index: 1}, {Index: 2}, {Index: -1}, {Index: 7}}
+	s.locate(violations)
+	var got []int
+	for _, v := range violations {
+		got = append(got, v.Index)
+	}
+	if want := []int{3, -1, -1, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("located violations at %v, want %v", got, want)
+	}
+}
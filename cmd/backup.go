@@ -0,0 +1,48 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+var backupFile string
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Save the records, settings, synonyms and rules of the configured index to an archive",
+	Run: func(cmd *cobra.Command, args []string) {
+		if backupFile == "" {
+			backupFile = fmt.Sprintf("%s-%s.ndjson.gz", config.AlgoliaIndexName, time.Now().Format("20060102-150405"))
+		}
+
+		fmt.Printf("Backing up index %s to %s\n", config.AlgoliaIndexName, backupFile)
+		count, err := config.Backup(backupFile)
+		if err != nil {
+			log.WithError(err).WithField("file", backupFile).Fatal("Failed to back up index")
+		}
+		fmt.Printf("Saved %d records\n", count)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.Flags().StringVarP(&backupFile, "output", "o", "", "The archive to write, gzipped if it ends in .gz (default is <index>-<timestamp>.ndjson.gz)")
+}
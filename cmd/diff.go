@@ -0,0 +1,92 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+var diffFormat string
+
+// diffErrorStatus is the exit status of diff when it fails, kept apart from
+// the status 1 that reports differences
+const diffErrorStatus = 2
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how the index would change if the JSON file were uploaded",
+	Long: `Show how the index would change if the JSON file were uploaded.
+
+Nothing is changed on Algolia. The command exits with status 0 when the index
+matches, 1 when there are differences and 2 when it fails, so it can be used
+to gate deploys in CI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if diffFormat != "text" && diffFormat != "json" {
+			diffFailed(log.Log, fmt.Sprintf("Unknown format %q", diffFormat))
+		}
+
+		c, err := languageConfig()
+		if err != nil {
+			diffFailed(log.WithError(err), "Invalid languages")
+		}
+		diff, err := c.DiffIndex()
+		if err != nil {
+			diffFailed(log.WithError(err).WithField("file", c.UploadFile), "Failed to diff index")
+		}
+
+		switch diffFormat {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err = enc.Encode(diff); err != nil {
+				diffFailed(log.WithError(err), "Failed to write diff")
+			}
+		case "text":
+			for _, id := range diff.Added {
+				fmt.Printf("+ %s\n", id)
+			}
+			for _, id := range diff.Removed {
+				fmt.Printf("- %s\n", id)
+			}
+			for _, change := range diff.Modified {
+				fmt.Printf("~ %s (%s)\n", change.ObjectID, strings.Join(change.Attributes, ", "))
+			}
+			fmt.Printf("%d added, %d removed, %d modified\n", len(diff.Added), len(diff.Removed), len(diff.Modified))
+		}
+
+		if !diff.Empty() {
+			os.Exit(1)
+		}
+	},
+}
+
+// diffFailed logs the error and exits with diffErrorStatus
+func diffFailed(ctx log.Interface, msg string) {
+	ctx.Error(msg)
+	os.Exit(diffErrorStatus)
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	addSourceFlags(diffCmd)
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format (text or json)")
+}
@@ -0,0 +1,603 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+	"github.com/duckpuppy/algolia-hugo/app"
+	"github.com/spf13/cobra"
+)
+
+// exploreDelay is how long typing has to pause before the query is sent
+const exploreDelay = 150 * time.Millisecond
+
+// exploreMaxFacetRows is the most facets shown below the results
+const exploreMaxFacetRows = 3
+
+var exploreFacets []string
+
+// exploreCmd represents the explore command
+var exploreCmd = &cobra.Command{
+	Use:   "explore",
+	Short: "Explore the index in an interactive terminal UI",
+	Long: `Explore the index in an interactive terminal UI.
+
+Type to search, and the hits, the attributes of the selected record and the
+counts of the facet values are updated as you type. The facets shown are the
+searchable facets of the index, or those given with --facet.
+
+Keys:
+  Up, Down, ^P, ^N  select a hit
+  PgUp, PgDn        scroll the record
+  Enter, ^O         open the URL of the record in the browser
+  ^Y                copy the objectID of the record to the clipboard
+  ^U                clear the query
+  Esc, ^C           quit`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := explore(); err != nil {
+			log.WithError(err).Fatal("Failed to explore the index")
+		}
+	},
+}
+
+// Keys read from the terminal
+const (
+	keyUnknown = iota
+	keyRune
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyBackspace
+	keyClear
+	keyCopy
+	keyQuit
+)
+
+type key struct {
+	code int
+	r    rune
+}
+
+// parseKeys splits the bytes read from a raw terminal into keys
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		k, n := key{}, 1
+		switch b[0] {
+		case 0x1b:
+			switch {
+			case len(b) == 1:
+				k.code = keyQuit
+			case b[1] == '[' || b[1] == 'O':
+				// A control sequence ends with a byte from @ to ~
+				n = 2
+				for n < len(b) && (b[n] < 0x40 || b[n] > 0x7e) {
+					n++
+				}
+				if n < len(b) {
+					k.code = sequenceKey(string(b[2 : n+1]))
+					n++
+				}
+			default:
+				n = 2
+			}
+		case 0x03, 0x04:
+			k.code = keyQuit
+		case '\r', '\n':
+			k.code = keyEnter
+		case 0x7f, 0x08:
+			k.code = keyBackspace
+		case 0x0f:
+			k.code = keyEnter
+		case 0x10:
+			k.code = keyUp
+		case 0x0e:
+			k.code = keyDown
+		case 0x15:
+			k.code = keyClear
+		case 0x19:
+			k.code = keyCopy
+		default:
+			if b[0] >= 0x20 {
+				k.r, n = utf8.DecodeRune(b)
+				k.code = keyRune
+			}
+		}
+		keys = append(keys, k)
+		b = b[n:]
+	}
+	return keys
+}
+
+func sequenceKey(seq string) int {
+	switch seq {
+	case "A":
+		return keyUp
+	case "B":
+		return keyDown
+	case "5~":
+		return keyPageUp
+	case "6~":
+		return keyPageDown
+	}
+	return keyUnknown
+}
+
+// readKeys sends the keys typed on the terminal until it is closed
+func readKeys(keys chan<- key) {
+	buf := make([]byte, 256)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// exploreResult is the response to one query of the explorer
+type exploreResult struct {
+	seq      int
+	res      algoliasearch.QueryRes
+	facets   map[string][]algoliasearch.FacetHit
+	err      error
+	facetErr error
+}
+
+// explorer holds the state of the explore UI
+type explorer struct {
+	term          *terminal
+	out           *bufio.Writer
+	index         algoliasearch.Index
+	facets        []string
+	width, height int
+
+	query     string
+	seq       int
+	cancel    context.CancelFunc
+	searching bool
+	res       algoliasearch.QueryRes
+	facetHits map[string][]algoliasearch.FacetHit
+
+	selected int
+	top      int
+	scroll   int
+	status   string
+}
+
+func explore() error {
+	facets := exploreFacets
+	if len(facets) == 0 {
+		var err error
+		if facets, err = config.SearchableFacets(); err != nil {
+			return err
+		}
+	}
+
+	t, err := openTerminal()
+	if err != nil {
+		return err
+	}
+
+	e := &explorer{
+		term:   t,
+		out:    bufio.NewWriter(os.Stdout),
+		index:  config.GetIndex(),
+		facets: facets,
+	}
+	e.width, e.height = t.size()
+
+	// Draw on the alternate screen so the shell comes back as it was
+	fmt.Fprint(e.out, "\x1b[?1049h")
+	defer func() {
+		fmt.Fprint(e.out, "\x1b[?25h\x1b[?1049l")
+		_ = e.out.Flush()
+		_ = t.restore()
+	}()
+
+	return e.run()
+}
+
+func (e *explorer) run() error {
+	keys := make(chan key, 16)
+	go readKeys(keys)
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	results := make(chan exploreResult)
+	defer func() { e.cancel() }()
+
+	e.search(results)
+	var delay <-chan time.Time
+	for {
+		if err := e.render(); err != nil {
+			return err
+		}
+
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			query := e.query
+			if e.handleKey(k) {
+				return nil
+			}
+			if e.query != query {
+				delay = time.After(exploreDelay)
+			}
+		case <-delay:
+			delay = nil
+			e.search(results)
+		case r := <-results:
+			// Responses to queries that have since changed are dropped
+			if r.seq == e.seq {
+				e.apply(r)
+			}
+		case <-resize:
+			e.width, e.height = e.term.size()
+		}
+	}
+}
+
+// search sends the current query in the background, abandoning the
+// previous query if it is still running
+func (e *explorer) search(results chan<- exploreResult) {
+	if e.cancel != nil {
+		e.cancel()
+	}
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+
+	e.seq++
+	e.searching = true
+	seq, query, index, facets := e.seq, e.query, e.index, e.facets
+
+	go func() {
+		r := exploreResult{seq: seq, facets: map[string][]algoliasearch.FacetHit{}}
+		if r.res, r.err = app.Search(index, query, app.SearchOptions{HitsPerPage: 50}); r.err == nil {
+			for _, facet := range facets {
+				if ctx.Err() != nil {
+					return
+				}
+				if r.facets[facet], r.facetErr = app.FacetValues(index, facet, query); r.facetErr != nil {
+					break
+				}
+			}
+		}
+
+		select {
+		case results <- r:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// apply shows the response to a query. When only the facet values failed,
+// the hits are still shown.
+func (e *explorer) apply(r exploreResult) {
+	e.searching = false
+	if r.err != nil {
+		e.status = "Search failed: " + r.err.Error()
+		return
+	}
+	e.res, e.facetHits = r.res, r.facets
+	e.selected, e.top, e.scroll = 0, 0, 0
+	e.status = ""
+	if r.facetErr != nil {
+		e.status = "Facet values failed: " + r.facetErr.Error()
+	}
+}
+
+// handleKey updates the state for a key, and reports whether to quit
+func (e *explorer) handleKey(k key) bool {
+	switch k.code {
+	case keyQuit:
+		return true
+	case keyRune:
+		e.query += string(k.r)
+	case keyBackspace:
+		if r := []rune(e.query); len(r) > 0 {
+			e.query = string(r[:len(r)-1])
+		}
+	case keyClear:
+		e.query = ""
+	case keyUp:
+		if e.selected > 0 {
+			e.selected--
+			e.scroll = 0
+		}
+	case keyDown:
+		if e.selected < len(e.res.Hits)-1 {
+			e.selected++
+			e.scroll = 0
+		}
+	case keyPageUp:
+		e.scroll -= e.listHeight()
+	case keyPageDown:
+		e.scroll += e.listHeight()
+	case keyEnter:
+		e.open()
+	case keyCopy:
+		e.copy()
+	}
+	return false
+}
+
+// hit returns the selected hit, or nil when there are none
+func (e *explorer) hit() algoliasearch.Map {
+	if e.selected < len(e.res.Hits) {
+		return e.res.Hits[e.selected]
+	}
+	return nil
+}
+
+func (e *explorer) open() {
+	hit := e.hit()
+	if hit == nil {
+		return
+	}
+
+	u := app.ObjectURL(algoliasearch.Object(hit))
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		e.status = "The record has no absolute URL to open"
+		return
+	}
+	if err := openURL(u); err != nil {
+		e.status = "Failed to open the URL: " + err.Error()
+		return
+	}
+	e.status = "Opened " + u
+}
+
+// copy puts the objectID on the clipboard with the OSC 52 escape sequence,
+// which also works over SSH in the terminals that support it
+func (e *explorer) copy() {
+	hit := e.hit()
+	if hit == nil {
+		return
+	}
+
+	id, _ := hit["objectID"].(string)
+	fmt.Fprintf(e.out, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(id)))
+	e.status = "Copied objectID " + id
+}
+
+func (e *explorer) facetRows() int {
+	rows := len(e.facets)
+	if rows > exploreMaxFacetRows {
+		rows = exploreMaxFacetRows
+	}
+	if rows == 0 {
+		rows = 1
+	}
+	return rows
+}
+
+// listHeight is the number of rows of the results list
+func (e *explorer) listHeight() int {
+	h := e.height - 4 - e.facetRows()
+	if h < 1 {
+		return 1
+	}
+	return h
+}
+
+// render redraws the whole screen
+func (e *explorer) render() error {
+	w := e.width
+	listHeight := e.listHeight()
+	listWidth := w * 2 / 5
+	if listWidth < 20 {
+		listWidth = 20
+	}
+	detailWidth := w - listWidth - 3
+	if detailWidth < 1 {
+		detailWidth = 1
+	}
+
+	var lines []string
+
+	prompt := " Search: " + e.query
+	info := fmt.Sprintf("%d hits  %dms  %s ", e.res.NbHits, e.res.ProcessingTimeMS, config.AlgoliaIndexName)
+	if e.searching {
+		info = "searching…  " + info
+	}
+	if n := w - utf8.RuneCountInString(info); n > utf8.RuneCountInString(prompt) {
+		lines = append(lines, fit(prompt, n)+info)
+	} else {
+		lines = append(lines, fit(prompt, w))
+	}
+	sep := strings.Repeat("─", w)
+	lines = append(lines, sep)
+
+	// Keep the selected hit in view
+	if e.selected < e.top {
+		e.top = e.selected
+	}
+	if e.selected >= e.top+listHeight {
+		e.top = e.selected - listHeight + 1
+	}
+
+	detail := e.detailLines(detailWidth)
+	if last := len(detail) - listHeight; e.scroll > last {
+		e.scroll = last
+	}
+	if e.scroll < 0 {
+		e.scroll = 0
+	}
+
+	for i := 0; i < listHeight; i++ {
+		row := fit("", listWidth)
+		if n := e.top + i; n < len(e.res.Hits) {
+			hit := e.res.Hits[n]
+			title := app.HitTitle(hit)
+			if title == "" {
+				title, _ = hit["objectID"].(string)
+			}
+			row = fit(fmt.Sprintf(" %2d  %s", n+1, title), listWidth)
+			if n == e.selected {
+				row = "\x1b[7m" + row + "\x1b[27m"
+			}
+		}
+		d := ""
+		if j := e.scroll + i; j < len(detail) {
+			d = detail[j]
+		}
+		lines = append(lines, row+" │ "+fit(d, detailWidth))
+	}
+
+	lines = append(lines, sep)
+	lines = append(lines, e.facetLines(w)...)
+	help := " ↑↓ select  PgUp/PgDn scroll  Enter open URL  ^Y copy objectID  ^U clear  Esc quit"
+	if e.status != "" {
+		help = " " + e.status
+	}
+	lines = append(lines, fit(help, w))
+
+	fmt.Fprint(e.out, "\x1b[?25l")
+	for i, line := range lines {
+		if i >= e.height {
+			break
+		}
+		fmt.Fprintf(e.out, "\x1b[%d;1H%s\x1b[K", i+1, line)
+	}
+	col := utf8.RuneCountInString(prompt) + 1
+	if col > w {
+		col = w
+	}
+	fmt.Fprintf(e.out, "\x1b[1;%dH\x1b[?25h", col)
+	return e.out.Flush()
+}
+
+// detailLines renders every attribute of the selected hit, wrapped to width
+func (e *explorer) detailLines(width int) []string {
+	hit := e.hit()
+	if hit == nil {
+		return nil
+	}
+
+	var attrs []string
+	for attr := range hit {
+		// Leave out _highlightResult and the like
+		if !strings.HasPrefix(attr, "_") && attr != "objectID" {
+			attrs = append(attrs, attr)
+		}
+	}
+	sort.Strings(attrs)
+	attrs = append([]string{"objectID"}, attrs...)
+
+	var lines []string
+	for _, attr := range attrs {
+		value, ok := hit[attr].(string)
+		if !ok {
+			b, _ := json.Marshal(hit[attr])
+			value = string(b)
+		}
+		lines = append(lines, wrap(attr+": "+value, width)...)
+	}
+	return lines
+}
+
+// facetLines renders the counts of the facet values, one facet per line
+func (e *explorer) facetLines(width int) []string {
+	if len(e.facets) == 0 {
+		return []string{fit(" No searchable facets, choose some with --facet", width)}
+	}
+
+	var lines []string
+	for _, facet := range e.facets[:e.facetRows()] {
+		var values []string
+		for _, h := range e.facetHits[facet] {
+			values = append(values, fmt.Sprintf("%s (%d)", h.Value, h.Count))
+		}
+		lines = append(lines, fit(" "+facet+": "+strings.Join(values, "  "), width))
+	}
+	return lines
+}
+
+// fit pads or truncates s to exactly width characters, replacing control
+// characters so they cannot upset the screen
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	r := []rune(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s))
+	if len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return string(r) + strings.Repeat(" ", width-len(r))
+}
+
+// wrap breaks s into lines of at most width characters at spaces, breaking
+// words that are too long. The lines after the first are indented.
+func wrap(s string, width int) []string {
+	if width < 4 {
+		return []string{s}
+	}
+
+	var lines []string
+	var line []rune
+	start := 0
+	newLine := func() {
+		lines = append(lines, string(line))
+		line, start = []rune("  "), 2
+	}
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		if len(line) > start {
+			if len(line)+1+len(w) > width {
+				newLine()
+			} else {
+				line = append(line, ' ')
+			}
+		}
+		for len(line)+len(w) > width {
+			n := width - len(line)
+			line = append(line, w[:n]...)
+			w = w[n:]
+			newLine()
+		}
+		line = append(line, w...)
+	}
+	return append(lines, string(line))
+}
+
+func init() {
+	rootCmd.AddCommand(exploreCmd)
+	exploreCmd.Flags().StringSliceVar(&exploreFacets, "facet", nil, "Facets to show the value counts of (default the searchable facets of the index)")
+}
@@ -0,0 +1,110 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []key
+	}{
+		{"ab", []key{{keyRune, 'a'}, {keyRune, 'b'}}},
+		{"é日", []key{{keyRune, 'é'}, {keyRune, '日'}}},
+		{"\x1b[A\x1b[B\x1bOA", []key{{code: keyUp}, {code: keyDown}, {code: keyUp}}},
+		{"\x1b[5~\x1b[6~", []key{{code: keyPageUp}, {code: keyPageDown}}},
+		{"\x1b[1;5C", []key{{code: keyUnknown}}},
+		{"\x1b", []key{{code: keyQuit}}},
+		{"\x1bx", []key{{code: keyUnknown}}},
+		{"\x1b[", []key{{code: keyUnknown}}},
+		{"\r\n\x0f", []key{{code: keyEnter}, {code: keyEnter}, {code: keyEnter}}},
+		{"\x7f\x08", []key{{code: keyBackspace}, {code: keyBackspace}}},
+		{"\x10\x0e\x15\x19", []key{{code: keyUp}, {code: keyDown}, {code: keyClear}, {code: keyCopy}}},
+		{"\x03\x04", []key{{code: keyQuit}, {code: keyQuit}}},
+		{"\x01a", []key{{code: keyUnknown}, {keyRune, 'a'}}},
+	}
+
+	for _, tt := range tests {
+		if got := parseKeys([]byte(tt.in)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseKeys(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"one two three four", 10, []string{"one two", "  three", "  four"}},
+		{"  spaced\n\tout  ", 20, []string{"spaced out"}},
+		{"abcdefghijkl", 5, []string{"abcde", "  fgh", "  ijk", "  l"}},
+		{"ab abcdefgh", 6, []string{"ab", "  abcd", "  efgh"}},
+		{"日本語のテキスト", 5, []string{"日本語のテ", "  キスト"}},
+		{"too narrow", 3, []string{"too narrow"}},
+	}
+
+	for _, tt := range tests {
+		if got := wrap(tt.in, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc  "},
+		{"abcdef", 4, "abc…"},
+		{"a\tb\x1b", 4, "a b "},
+		{"abc", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := fit(tt.in, tt.width); got != tt.want {
+			t.Errorf("fit(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestExplorerApply(t *testing.T) {
+	e := &explorer{seq: 2, selected: 3}
+	hits := algoliasearch.QueryRes{NbHits: 1, Hits: []algoliasearch.Map{{"objectID": "a"}}}
+
+	e.apply(exploreResult{seq: 2, res: hits, facetErr: errors.New("not searchable")})
+	if e.res.NbHits != 1 || e.selected != 0 {
+		t.Errorf("hits were not shown when the facet values failed: %+v", e.res)
+	}
+	if e.status != "Facet values failed: not searchable" {
+		t.Errorf("status = %q", e.status)
+	}
+
+	e.apply(exploreResult{seq: 2, err: errors.New("down")})
+	if e.res.NbHits != 1 || e.status != "Search failed: down" {
+		t.Errorf("a failed search replaced the hits or was not reported: %+v %q", e.res, e.status)
+	}
+}
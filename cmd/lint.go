@@ -0,0 +1,134 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/duckpuppy/algolia-hugo/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintIndex  bool
+	lintFormat string
+	lintFailOn string
+	lintList   bool
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Find content quality problems in the records",
+	Long: `Find content quality problems in the records, such as pages without a
+title, duplicate URLs, placeholder text or 404 pages.
+
+Every finding has the severity of its rule. Rules are enabled, disabled and
+tuned in the lint section of the config file, and --list-rules shows them all.
+The command exits with status 1 when any finding is at least as severe as
+--fail-on. Use --format github to annotate a GitHub Actions run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if lintList {
+			listLintRules()
+			return
+		}
+		if !app.ValidSeverity(lintFailOn) {
+			log.Fatalf("Unknown severity %q", lintFailOn)
+		}
+
+		c, err := languageConfig()
+		if err != nil {
+			log.WithError(err).Fatal("Invalid languages")
+		}
+		var findings []app.LintFinding
+		if lintIndex {
+			findings, err = c.LintIndex()
+		} else {
+			findings, err = c.LintObjects()
+		}
+		if err != nil {
+			log.WithError(err).Fatal("Failed to lint the records")
+		}
+
+		switch lintFormat {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if findings == nil {
+				findings = []app.LintFinding{}
+			}
+			if err = enc.Encode(findings); err != nil {
+				log.WithError(err).Fatal("Failed to write findings")
+			}
+		case "github":
+			for _, f := range findings {
+				fmt.Println(githubAnnotation(f))
+			}
+		case "text":
+			for _, f := range findings {
+				fmt.Println(f)
+			}
+			fmt.Printf("%d findings\n", len(findings))
+		default:
+			log.Fatalf("Unknown format %q", lintFormat)
+		}
+
+		for _, f := range findings {
+			if f.AtLeast(lintFailOn) {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// githubAnnotation formats a finding as a GitHub Actions workflow command
+func githubAnnotation(f app.LintFinding) string {
+	level := f.Severity
+	if level == app.SeverityInfo {
+		level = "notice"
+	}
+
+	location := fmt.Sprintf("record %d", f.Index)
+	if f.URL != "" {
+		location += " (" + f.URL + ")"
+	}
+	escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	return fmt.Sprintf("::%s title=%s::%s", level, escapeProperty.Replace("lint "+f.Rule), escape.Replace(location+": "+f.Message))
+}
+
+func listLintRules() {
+	rules := app.LintRules()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tSEVERITY\tDESCRIPTION")
+	for _, name := range app.LintRuleNames() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, rules[name].Defaults.Severity, rules[name].Description)
+	}
+	_ = w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	addSourceFlags(lintCmd)
+	lintCmd.Flags().BoolVar(&lintIndex, "index", false, "Lint the records of the index instead of the records to upload")
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format (text, json or github)")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", app.SeverityError, "Exit with status 1 on findings of this severity or worse (error, warning or info)")
+	lintCmd.Flags().BoolVar(&lintList, "list-rules", false, "List the rules and their default severities")
+}
@@ -0,0 +1,252 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	profile     string
+	allProfiles bool
+)
+
+// readConfigFile reads the settings of the config file alone, without the
+// flags, environment and defaults that viper layers on top
+func readConfigFile() (map[string]interface{}, error) {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		return nil, fmt.Errorf("profiles need a config file")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// profileNames lists the profiles of the config file in order
+func profileNames() ([]string, error) {
+	settings, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, _ := settings["profiles"].(map[string]interface{})
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// applyProfile replaces the settings of the config file with the named
+// profile laid over the top level settings, which act as the default block.
+// Flags and environment variables still take precedence.
+func applyProfile(name string) error {
+	settings, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+
+	profiles, _ := settings["profiles"].(map[string]interface{})
+	p, ok := profiles[strings.ToLower(name)].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	delete(settings, "profiles")
+
+	b, err := yaml.Marshal(mergeProfile(settings, p))
+	if err != nil {
+		return err
+	}
+	viper.SetConfigType("yaml")
+	return viper.ReadConfig(bytes.NewReader(b))
+}
+
+// mergeProfile lays the settings of a profile over the defaults. Nested
+// sections are merged key by key, while lists and values are replaced.
+func mergeProfile(defaults, profile map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaults)+len(profile))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range profile {
+		if pm, ok := v.(map[string]interface{}); ok {
+			if dm, ok := merged[k].(map[string]interface{}); ok {
+				v = mergeProfile(dm, pm)
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// profileResult is the outcome of running the command with one profile
+type profileResult struct {
+	name     string
+	status   int
+	reason   string
+	duration time.Duration
+}
+
+// ansiEscape matches the color codes of the log output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// stderrTail passes the error output of a profile through while keeping its
+// end, which holds the reason the profile failed
+type stderrTail struct {
+	w    io.Writer
+	tail []byte
+}
+
+// stderrTailSize is how much of the error output of a profile is kept
+const stderrTailSize = 4096
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.tail = append(t.tail, p...)
+	if len(t.tail) > stderrTailSize {
+		t.tail = t.tail[len(t.tail)-stderrTailSize:]
+	}
+	return t.w.Write(p)
+}
+
+// summary returns the last line of the error output, without colors or padding
+func (t *stderrTail) summary() string {
+	lines := strings.Split(ansiEscape.ReplaceAllString(string(t.tail), ""), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.Join(strings.Fields(lines[i]), " "); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// exitStatus returns the exit status of a finished command, or -1 when it
+// could not be run or was killed by a signal
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Exited() {
+			return ws.ExitStatus()
+		}
+	}
+	return -1
+}
+
+// runAllProfiles runs the command line once per profile, in sequence, and
+// prints a combined report with the exit status and last error of every
+// failed profile. It exits with the highest exit status of the profiles.
+func runAllProfiles() {
+	if profile != "" {
+		log.Fatal("--profile and --all-profiles cannot be used together")
+	}
+
+	names, err := profileNames()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read the profiles")
+	}
+	if len(names) == 0 {
+		log.Fatal("The config file has no profiles")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to find the executable")
+	}
+
+	var args []string
+	for _, arg := range os.Args[1:] {
+		if !strings.HasPrefix(arg, "--all-profiles") {
+			args = append(args, arg)
+		}
+	}
+
+	var results []profileResult
+	for _, name := range names {
+		log.WithField("profile", name).Info("Running profile")
+
+		stderr := &stderrTail{w: os.Stderr}
+		child := exec.Command(exe, append([]string{"--profile", name}, args...)...)
+		child.Stdin = os.Stdin
+		child.Stdout = os.Stdout
+		child.Stderr = stderr
+
+		start := time.Now()
+		runErr := child.Run()
+		r := profileResult{name: name, status: exitStatus(runErr), duration: time.Since(start)}
+		if runErr != nil {
+			if r.reason = stderr.summary(); r.reason == "" {
+				r.reason = runErr.Error()
+			}
+		}
+		results = append(results, r)
+	}
+
+	os.Exit(printProfileResults(os.Stdout, results))
+}
+
+// printProfileResults prints the report of runAllProfiles and returns the
+// status to exit with
+func printProfileResults(out io.Writer, results []profileResult) int {
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tRESULT\tTIME\tREASON")
+	failed, status := 0, 0
+	for _, r := range results {
+		result := "ok"
+		switch {
+		case r.status > 0:
+			result = fmt.Sprintf("failed (status %d)", r.status)
+		case r.status < 0:
+			result = "failed"
+		}
+		if r.status != 0 {
+			failed++
+			// A profile that could not run or was killed counts as status 1
+			if s := r.status; s > status {
+				status = s
+			} else if status == 0 {
+				status = 1
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.name, result, r.duration.Round(time.Millisecond), r.reason)
+	}
+	_ = w.Flush()
+
+	if failed > 0 {
+		fmt.Fprintf(out, "%d of %d profiles failed\n", failed, len(results))
+	}
+	return status
+}
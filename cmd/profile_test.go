@@ -0,0 +1,219 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMergeProfile(t *testing.T) {
+	defaults := map[string]interface{}{
+		"algolia_index_name": "docs",
+		"upload_file":        "public/index.json",
+		"split":              map[string]interface{}{"attribute": "content", "max_size": 10000},
+		"transforms":         []interface{}{"a", "b"},
+	}
+	profile := map[string]interface{}{
+		"algolia_index_name": "docs_staging",
+		"split":              map[string]interface{}{"max_size": 5000},
+		"transforms":         []interface{}{"c"},
+		"dry_run":            true,
+	}
+
+	got := mergeProfile(defaults, profile)
+	want := map[string]interface{}{
+		"algolia_index_name": "docs_staging",
+		"upload_file":        "public/index.json",
+		"split":              map[string]interface{}{"attribute": "content", "max_size": 5000},
+		"transforms":         []interface{}{"c"},
+		"dry_run":            true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeProfile = %v, want %v", got, want)
+	}
+	if defaults["algolia_index_name"] != "docs" || defaults["split"].(map[string]interface{})["max_size"] != 10000 {
+		t.Errorf("mergeProfile changed the defaults: %v", defaults)
+	}
+}
+
+// withConfigFile points viper at a config file with the given contents
+func withConfigFile(t *testing.T, contents string) func() {
+	dir, err := ioutil.TempDir("", "algolia-hugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "algolia-hugo.yaml")
+	if err = ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Reset()
+	viper.SetConfigFile(file)
+	if err = viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		viper.Reset()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	defer withConfigFile(t, `
+algolia_index_name: docs
+upload_file: public/index.json
+split:
+  attribute: content
+  max_size: 10000
+profiles:
+  staging:
+    algolia_index_name: docs_staging
+    split:
+      max_size: 5000
+`)()
+	viper.SetDefault("upload_file", "default.json")
+	viper.SetDefault("dry_run", true)
+
+	if err := applyProfile("Staging"); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{
+		"algolia_index_name": viper.GetString("algolia_index_name"),
+		"upload_file":        viper.GetString("upload_file"),
+		"split.attribute":    viper.GetString("split.attribute"),
+		"split.max_size":     viper.GetInt("split.max_size"),
+		"dry_run":            viper.GetBool("dry_run"),
+		"profiles":           viper.Get("profiles"),
+	}
+	want := map[string]interface{}{
+		"algolia_index_name": "docs_staging",
+		"upload_file":        "public/index.json",
+		"split.attribute":    "content",
+		"split.max_size":     5000,
+		"dry_run":            true,
+		"profiles":           nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("settings with the profile = %v, want %v", got, want)
+	}
+
+	// Flags and environment variables still override the profile
+	viper.Set("upload_file", "flag.json")
+	if err := os.Setenv("ALGOLIA_INDEX_NAME", "docs_env"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("ALGOLIA_INDEX_NAME")
+	viper.AutomaticEnv()
+	if name, file := viper.GetString("algolia_index_name"), viper.GetString("upload_file"); name != "docs_env" || file != "flag.json" {
+		t.Errorf("index %s and file %s, want the environment and the flag to win", name, file)
+	}
+}
+
+func TestApplyProfileErrors(t *testing.T) {
+	defer withConfigFile(t, "profiles:\n  staging:\n    algolia_index_name: docs_staging\n")()
+	if err := applyProfile("production"); err == nil || err.Error() != `unknown profile "production"` {
+		t.Errorf("applyProfile(production): error = %v", err)
+	}
+
+	viper.Reset()
+	if err := applyProfile("staging"); err == nil || err.Error() != "profiles need a config file" {
+		t.Errorf("applyProfile without a config file: error = %v", err)
+	}
+}
+
+func TestStderrTail(t *testing.T) {
+	var out bytes.Buffer
+	tail := &stderrTail{w: &out}
+	_, _ = tail.Write([]byte("\x1b[34m   •\x1b[0m Loading   records=3\n"))
+	_, _ = tail.Write([]byte("\x1b[31m   ⨯\x1b[0m Failed to diff index     \x1b[31merror\x1b[0m=no such"))
+	_, _ = tail.Write([]byte(" file\n\n"))
+
+	if got, want := tail.summary(), "⨯ Failed to diff index error=no such file"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	if !strings.Contains(out.String(), "Loading") {
+		t.Errorf("the error output was not passed through: %q", out.String())
+	}
+
+	_, _ = tail.Write(bytes.Repeat([]byte("x"), 2*stderrTailSize))
+	if len(tail.tail) != stderrTailSize {
+		t.Errorf("kept %d bytes, want %d", len(tail.tail), stderrTailSize)
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	if s := exitStatus(exec.Command("sh", "-c", "exit 3").Run()); s != 3 {
+		t.Errorf("exitStatus of exit 3 = %d", s)
+	}
+	if s := exitStatus(nil); s != 0 {
+		t.Errorf("exitStatus of success = %d", s)
+	}
+	if s := exitStatus(errors.New("not found")); s != -1 {
+		t.Errorf("exitStatus of a command that did not run = %d", s)
+	}
+}
+
+func TestPrintProfileResults(t *testing.T) {
+	tests := []struct {
+		statuses []int
+		want     int
+	}{
+		{[]int{0, 0}, 0},
+		{[]int{0, 1}, 1},
+		{[]int{2, 1}, 2},
+		{[]int{-1, 0}, 1},
+		{[]int{-1, 2}, 2},
+	}
+
+	for _, tt := range tests {
+		var results []profileResult
+		for i, s := range tt.statuses {
+			r := profileResult{name: string('a' + rune(i)), status: s}
+			if s != 0 {
+				r.reason = "error=boom"
+			}
+			results = append(results, r)
+		}
+
+		var out bytes.Buffer
+		if got := printProfileResults(&out, results); got != tt.want {
+			t.Errorf("%v: status = %d, want %d", tt.statuses, got, tt.want)
+		}
+		if tt.want != 0 && !strings.Contains(out.String(), "error=boom") {
+			t.Errorf("%v: the report has no reason:\n%s", tt.statuses, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	printProfileResults(&out, []profileResult{{name: "staging", status: 2, reason: "error=boom"}, {name: "prod"}})
+	for _, want := range []string{"staging  failed (status 2)", "prod     ok", "1 of 2 profiles failed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the report has no %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	relevanceFile   string
+	relevanceFormat string
+)
+
+// testRelevanceCmd represents the test-relevance command
+var testRelevanceCmd = &cobra.Command{
+	Use:   "test-relevance",
+	Short: "Check that key queries still find the right pages",
+	Long: `Check that key queries still find the right pages.
+
+Every case of the relevance file is a query with expectations: a URL in the
+top hits, no results, a minimum number of hits or a facet value. The cases are
+run against the index and the command exits with status 1 when any of them
+fails, so search quality can be checked in CI.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		results, err := config.TestRelevance(relevanceFile)
+		if err != nil {
+			log.WithError(err).WithField("file", relevanceFile).Fatal("Failed to run relevance tests")
+		}
+
+		failed := 0
+		for _, r := range results {
+			if !r.Passed {
+				failed++
+			}
+		}
+
+		switch relevanceFormat {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err = enc.Encode(results); err != nil {
+				log.WithError(err).Fatal("Failed to write results")
+			}
+		case "text":
+			for _, r := range results {
+				status := "PASS"
+				if !r.Passed {
+					status = "FAIL"
+				}
+				fmt.Printf("%s %s\n", status, r.Name)
+				for _, f := range r.Failures {
+					fmt.Printf("     %s\n", f)
+				}
+			}
+			fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+		default:
+			log.Fatalf("Unknown format %q", relevanceFormat)
+		}
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testRelevanceCmd)
+	testRelevanceCmd.Flags().StringVarP(&relevanceFile, "file", "f", "relevance.yaml", "The YAML or JSON file of relevance cases")
+	testRelevanceCmd.Flags().StringVar(&relevanceFormat, "format", "text", "Output format (text or json)")
+}
@@ -0,0 +1,49 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+var restoreIndexName string
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Recreate the configured index from a backup archive",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := restoreIndexName
+		if name == "" {
+			name = config.AlgoliaIndexName
+		}
+
+		fmt.Printf("Restoring index %s from %s\n", name, args[0])
+		count, err := config.Restore(args[0], name)
+		if err != nil {
+			log.WithError(err).WithField("file", args[0]).Fatal("Failed to restore index")
+		}
+		fmt.Printf("Restored %d records\n", count)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringVar(&restoreIndexName, "index", "", "Restore into this index instead of the configured one")
+}
@@ -27,11 +27,35 @@ import (
 
 var config app.Config
 var cfgFile string
+var sourceLang string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "algolia-hugo",
 	Short: "Easily manage your search index on Algolia for your Hugo site",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if allProfiles {
+			// Exits with the status of the profiles, instead of running the command here
+			runAllProfiles()
+		}
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if !config.DryRun {
+			return
+		}
+
+		ops := config.DryRunOperations()
+		objects, bytes := 0, 0
+		for _, op := range ops {
+			objects += op.Objects
+			bytes += op.Bytes
+		}
+		log.WithFields(log.Fields{
+			"operations": len(ops),
+			"objects":    objects,
+			"bytes":      bytes,
+		}).Info("Dry run complete, nothing was sent to Algolia")
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -51,6 +75,12 @@ func init() {
 
 	rootCmd.PersistentFlags().BoolVarP(&config.Verbose, "verbose", "v", false, "Display verbose output")
 	_ = viper.BindPFlag("Verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+
+	rootCmd.PersistentFlags().BoolVar(&config.DryRun, "dry-run", false, "Log the changes that would be sent to Algolia without sending them")
+	_ = viper.BindPFlag("dry_run", rootCmd.PersistentFlags().Lookup("dry-run"))
+
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Use the named profile of the config file")
+	rootCmd.PersistentFlags().BoolVar(&allProfiles, "all-profiles", false, "Run the command once for every profile of the config file")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -71,6 +101,12 @@ func initConfig() {
 	// If a config file is found, read it in.
 	_ = viper.ReadInConfig()
 
+	if profile != "" {
+		if err := applyProfile(profile); err != nil {
+			log.WithError(err).Fatal("Failed to load the profile")
+		}
+	}
+
 	viper.AutomaticEnv() // bind to environment variables that match key names
 
 	// Unmarshal the config into the config variable
@@ -82,10 +118,37 @@ func initConfig() {
 	config.AlgoliaIndexName = viper.GetString("algolia_index_name")
 
 	if config.Verbose {
+		// Debug messages, such as one per crawled page, are only shown with --verbose
+		log.SetLevel(log.DebugLevel)
 		log.WithField("config", viper.ConfigFileUsed()).Info("Loaded config")
 	}
 }
 
+// addSourceFlags adds the flags choosing where the records to upload come from
+func addSourceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&config.UploadFile, "file", "f", "public/index.json", "The JSON file of records")
+	cmd.Flags().BoolVar(&config.FromContent, "from-content", false, "Build the records from the Hugo content directory instead of a JSON file")
+	cmd.Flags().BoolVar(&config.FromPublic, "from-public", false, "Crawl the HTML of the rendered site into DocSearch records instead of reading a JSON file")
+	cmd.Flags().StringVar(&config.SiteDir, "site", ".", "The root directory of the Hugo site, used with --from-content and --from-public")
+	cmd.Flags().StringVar(&sourceLang, "lang", "", "The language of the languages in the config file to use")
+}
+
+// languageConfig returns the configuration of the language chosen with --lang
+// when the config file has languages, since each language has its own records
+// and index, and the configuration itself otherwise
+func languageConfig() (*app.Config, error) {
+	if len(config.Languages) == 0 {
+		if sourceLang != "" {
+			return nil, fmt.Errorf("--lang needs languages in the config file")
+		}
+		return &config, nil
+	}
+	if sourceLang == "" {
+		return nil, fmt.Errorf("the config file has languages, choose one with --lang")
+	}
+	return config.LanguageConfig(sourceLang)
+}
+
 func setDefaults() {
 	viper.SetDefault("UploadFile", "public/index.json")
 	viper.SetDefault("Verbose", false)
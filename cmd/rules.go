@@ -0,0 +1,92 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesFile              string
+	rulesForwardToReplicas bool
+)
+
+// rulesCmd represents the rules command
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage the query rules of the configured index",
+}
+
+// rulesExportCmd represents the rules export command
+var rulesExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all query rules of the index to the rules file",
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := config.ExportRules(rulesFile)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to export rules")
+		}
+		fmt.Printf("Exported %d rules to %s\n", count, rulesFile)
+	},
+}
+
+// rulesApplyCmd represents the rules apply command
+var rulesApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Replace all query rules of the index with the rules file",
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := config.ApplyRules(rulesFile, rulesForwardToReplicas)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to apply rules")
+		}
+		fmt.Printf("Replaced rules of %s with %d from %s\n", config.AlgoliaIndexName, count, rulesFile)
+	},
+}
+
+// rulesDiffCmd represents the rules diff command
+var rulesDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the query rules that differ between the rules file and the index",
+	Run: func(cmd *cobra.Command, args []string) {
+		diff, err := config.DiffRules(rulesFile)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to diff rules")
+		}
+
+		if diff.Empty() {
+			fmt.Println("No differences")
+			return
+		}
+		for _, id := range diff.Added {
+			fmt.Printf("+ %s\n", id)
+		}
+		for _, id := range diff.Removed {
+			fmt.Printf("- %s\n", id)
+		}
+		for _, id := range diff.Modified {
+			fmt.Printf("~ %s\n", id)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesExportCmd, rulesApplyCmd, rulesDiffCmd)
+	rulesCmd.PersistentFlags().StringVarP(&rulesFile, "file", "f", "algolia-rules.yaml", "The rules file (YAML or JSON)")
+	rulesCmd.PersistentFlags().BoolVar(&rulesForwardToReplicas, "forward-to-replicas", false, "Also apply changes to the replicas of the index")
+}
@@ -0,0 +1,106 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/algolia/algoliasearch-client-go/algoliasearch"
+	"github.com/apex/log"
+	"github.com/duckpuppy/algolia-hugo/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchOptions app.SearchOptions
+	searchFormat  string
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the index and show the hits",
+	Long: `Search the index and show the hits, ranked as the site's search would rank them.
+
+The query is sent with the given filters and parameters, and every other
+parameter comes from the index settings. Use --format json to see the raw
+response, including the ranking information Algolia returns.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if searchFormat != "table" && searchFormat != "json" {
+			log.Fatalf("Unknown format %q", searchFormat)
+		}
+
+		opts := searchOptions
+		if searchFormat == "table" && opts.Highlight {
+			// Matches are shown in bold on a terminal, and marked otherwise
+			opts.HighlightPreTag, opts.HighlightPostTag = "*", "*"
+			if isTerminal(os.Stdout) {
+				opts.HighlightPreTag, opts.HighlightPostTag = "\x1b[1m", "\x1b[22m"
+			}
+		}
+
+		res, err := config.Search(args[0], opts)
+		if err != nil {
+			log.WithError(err).WithField("query", args[0]).Fatal("Failed to search")
+		}
+
+		if searchFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err = enc.Encode(res); err != nil {
+				log.WithError(err).Fatal("Failed to write results")
+			}
+			return
+		}
+
+		// The snippet comes last so that its highlighting does not upset the alignment
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "RANK\tOBJECTID\tTITLE\tURL\tSNIPPET")
+		for i, hit := range res.Hits {
+			objectID, _ := hit["objectID"].(string)
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+				res.Page*res.HitsPerPage+i+1,
+				objectID,
+				app.HitTitle(hit),
+				app.ObjectURL(algoliasearch.Object(hit)),
+				app.HitSnippet(hit, opts.SnippetAttribute, opts.SnippetWords))
+		}
+		_ = w.Flush()
+		fmt.Printf("%d hits in %dms, page %d of %d\n", res.NbHits, res.ProcessingTimeMS, res.Page+1, res.NbPages)
+	},
+}
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().StringVar(&searchOptions.Filters, "filters", "", "Filter the hits, as in 'section:blog AND NOT draft:true'")
+	searchCmd.Flags().StringArrayVar(&searchOptions.FacetFilters, "facet-filter", nil, "Only show hits with a facet value, as in 'tags:go' (repeatable)")
+	searchCmd.Flags().IntVar(&searchOptions.HitsPerPage, "hits-per-page", 0, "Number of hits per page (default from the index settings)")
+	searchCmd.Flags().IntVar(&searchOptions.Page, "page", 0, "Page of hits to show, starting at 0")
+	searchCmd.Flags().StringSliceVar(&searchOptions.AttributesToRetrieve, "attributes", nil, "Attributes to retrieve (default from the index settings)")
+	searchCmd.Flags().BoolVar(&searchOptions.Highlight, "highlight", true, "Highlight the matches and snippet the content")
+	searchCmd.Flags().StringVar(&searchOptions.SnippetAttribute, "snippet", "content", "Attribute to show a snippet of")
+	searchCmd.Flags().IntVar(&searchOptions.SnippetWords, "snippet-words", 20, "Number of words in the snippet")
+	searchCmd.Flags().StringVar(&searchFormat, "format", "table", "Output format (table or json)")
+}
@@ -0,0 +1,90 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+)
+
+var settingsFile string
+
+// settingsCmd represents the settings command
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Manage the settings of the configured index as a file",
+}
+
+// settingsPullCmd represents the settings pull command
+var settingsPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Export the index settings to the settings file",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("Pulling settings of %s into %s\n", config.AlgoliaIndexName, settingsFile)
+		if err := config.PullSettings(settingsFile); err != nil {
+			log.WithError(err).Fatal("Failed to pull settings")
+		}
+	},
+}
+
+// settingsPushCmd represents the settings push command
+var settingsPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Apply the settings file to the index",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("Pushing settings from %s to %s\n", settingsFile, config.AlgoliaIndexName)
+		if err := config.PushSettings(settingsFile); err != nil {
+			log.WithError(err).Fatal("Failed to push settings")
+		}
+	},
+}
+
+// settingsDiffCmd represents the settings diff command
+var settingsDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the settings that differ between the settings file and the index",
+	Run: func(cmd *cobra.Command, args []string) {
+		changes, err := config.DiffSettings(settingsFile)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to diff settings")
+		}
+
+		if len(changes) == 0 {
+			fmt.Println("No differences")
+			return
+		}
+		for _, change := range changes {
+			fmt.Printf("%s:\n  - remote: %s\n  + local:  %s\n", change.Name, jsonString(change.Remote), jsonString(change.Local))
+		}
+	},
+}
+
+// jsonString renders a value as compact JSON for display
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func init() {
+	rootCmd.AddCommand(settingsCmd)
+	settingsCmd.AddCommand(settingsPullCmd, settingsPushCmd, settingsDiffCmd)
+	settingsCmd.PersistentFlags().StringVarP(&settingsFile, "file", "f", "algolia-settings.yaml", "The settings file (YAML or JSON)")
+}
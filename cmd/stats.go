@@ -0,0 +1,140 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/duckpuppy/algolia-hugo/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsOptions app.StatsOptions
+	statsLocal   bool
+	statsFormat  string
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report the size and shape of the records in the index",
+	Long: `Report the size and shape of the records in the index.
+
+The report has the number of records, the distribution of their sizes, the
+share of records that have each attribute, the number of values of each facet,
+the largest records and the number of records per section and language.
+
+With --local the report is computed for the records that update would upload
+instead, so the effect of a content change can be seen before it is sent.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statsFormat != "text" && statsFormat != "json" {
+			log.Fatalf("Unknown format %q", statsFormat)
+		}
+		if statsOptions.Largest < 0 {
+			log.Fatalf("--largest must not be negative, got %d", statsOptions.Largest)
+		}
+
+		opts := statsOptions
+		if !cmd.Flags().Changed("facet") {
+			// The facets come from the index settings
+			opts.Facets = nil
+		}
+
+		var stats app.IndexStats
+		var err error
+		if statsLocal {
+			c, lerr := languageConfig()
+			if lerr != nil {
+				log.WithError(lerr).Fatal("Invalid languages")
+			}
+			stats, err = c.LocalStats(opts)
+		} else {
+			stats, err = config.Stats(opts)
+		}
+		if err != nil {
+			log.WithError(err).Fatal("Failed to compute statistics")
+		}
+
+		if statsFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err = enc.Encode(stats); err != nil {
+				log.WithError(err).Fatal("Failed to write statistics")
+			}
+			return
+		}
+		printStats(stats)
+	},
+}
+
+func printStats(stats app.IndexStats) {
+	fmt.Printf("Records: %d\n", stats.Records)
+	s := stats.Size
+	fmt.Printf("Size:    %d bytes in total, min %d, median %d, p95 %d, max %d\n", s.Total, s.Min, s.Median, s.P95, s.Max)
+
+	// Every table is aligned on its own
+	table := func(header string, print func(w io.Writer)) {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, header)
+		print(w)
+		_ = w.Flush()
+	}
+
+	table("ATTRIBUTE\tRECORDS\tCOVERAGE", func(w io.Writer) {
+		for _, c := range stats.Coverage {
+			fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", c.Attribute, c.Records, c.Percent)
+		}
+	})
+	if len(stats.Facets) > 0 {
+		table("FACET\tVALUES", func(w io.Writer) {
+			for _, f := range stats.Facets {
+				fmt.Fprintf(w, "%s\t%d\n", f.Facet, f.Values)
+			}
+		})
+	}
+	if len(stats.Largest) > 0 {
+		table("LARGEST\tBYTES\tURL", func(w io.Writer) {
+			for _, r := range stats.Largest {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", r.ObjectID, r.Bytes, r.URL)
+			}
+		})
+	}
+	for _, g := range stats.Groups {
+		counts := g.Counts
+		table(strings.ToUpper(g.Attribute)+"\tRECORDS", func(w io.Writer) {
+			for _, c := range counts {
+				fmt.Fprintf(w, "%s\t%d\n", c.Value, c.Records)
+			}
+		})
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	addSourceFlags(statsCmd)
+	statsCmd.Flags().BoolVar(&statsLocal, "local", false, "Report on the records update would upload instead of the index")
+	statsCmd.Flags().StringSliceVar(&statsOptions.Facets, "facet", nil, "Facets to count the values of (default the attributesForFaceting of the settings)")
+	statsCmd.Flags().StringSliceVar(&statsOptions.GroupBy, "group-by", []string{"section", "lang"}, "Attributes to count the records by")
+	statsCmd.Flags().IntVar(&statsOptions.Largest, "largest", 10, "Number of largest records to list")
+	statsCmd.Flags().StringVar(&statsFormat, "format", "text", "Output format (text or json)")
+}
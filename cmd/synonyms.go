@@ -0,0 +1,97 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/duckpuppy/algolia-hugo/app"
+	"github.com/spf13/cobra"
+)
+
+var (
+	synonymsFile      string
+	forwardToReplicas bool
+)
+
+// synonymsCmd represents the synonyms command
+var synonymsCmd = &cobra.Command{
+	Use:   "synonyms",
+	Short: "Manage the synonyms of the configured index",
+}
+
+// synonymsExportCmd represents the synonyms export command
+var synonymsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all synonyms of the index to the synonyms file",
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := config.ExportSynonyms(synonymsFile)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to export synonyms")
+		}
+		fmt.Printf("Exported %d synonyms to %s\n", count, synonymsFile)
+	},
+}
+
+// synonymsReplaceCmd represents the synonyms replace command
+var synonymsReplaceCmd = &cobra.Command{
+	Use:   "replace",
+	Short: "Replace all synonyms of the index with the synonyms file",
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := config.ReplaceSynonyms(synonymsFile, forwardToReplicas)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to replace synonyms")
+		}
+		fmt.Printf("Replaced synonyms of %s with %d from %s\n", config.AlgoliaIndexName, count, synonymsFile)
+	},
+}
+
+// synonymsListCmd represents the synonyms list command
+var synonymsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the synonyms of the index",
+	Run: func(cmd *cobra.Command, args []string) {
+		synonyms, err := config.GetSynonyms()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to list synonyms")
+		}
+		for _, s := range synonyms {
+			fmt.Printf("%s\t%s\t%s\n", s.ObjectID, s.Type, app.FormatSynonym(s))
+		}
+	},
+}
+
+// synonymsDeleteCmd represents the synonyms delete command
+var synonymsDeleteCmd = &cobra.Command{
+	Use:   "delete <objectID>...",
+	Short: "Delete synonyms from the index",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, id := range args {
+			fmt.Printf("Deleting synonym: %s\n", id)
+			if err := config.DeleteSynonym(id, forwardToReplicas); err != nil {
+				log.WithError(err).WithField("objectID", id).Fatal("Failed to delete synonym")
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(synonymsCmd)
+	synonymsCmd.AddCommand(synonymsExportCmd, synonymsReplaceCmd, synonymsListCmd, synonymsDeleteCmd)
+	synonymsCmd.PersistentFlags().StringVarP(&synonymsFile, "file", "f", "algolia-synonyms.yaml", "The synonyms file (YAML or JSON)")
+	synonymsCmd.PersistentFlags().BoolVar(&forwardToReplicas, "forward-to-replicas", false, "Also apply changes to the replicas of the index")
+}
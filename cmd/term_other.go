@@ -0,0 +1,40 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// terminal is not supported on this platform
+type terminal struct{}
+
+func openTerminal() (*terminal, error) {
+	return nil, fmt.Errorf("explore is only available on Linux and macOS")
+}
+
+func (t *terminal) restore() error { return nil }
+
+func (t *terminal) size() (int, int) { return 80, 24 }
+
+func notifyResize(c chan<- os.Signal) {}
+
+func openURL(url string) error {
+	return fmt.Errorf("opening URLs is not supported on this platform")
+}
@@ -0,0 +1,86 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || darwin
+// +build linux darwin
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminal is the controlling terminal, switched to raw mode while exploring
+type terminal struct {
+	fd    int
+	saved unix.Termios
+}
+
+// openTerminal switches the terminal on standard input to raw mode, so keys
+// are read as they are pressed and are not echoed
+func openTerminal() (*terminal, error) {
+	fd := int(os.Stdin.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, fmt.Errorf("standard input is not a terminal")
+	}
+
+	raw := *saved
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err = unix.IoctlSetTermios(fd, ioctlWriteTermios, &raw); err != nil {
+		return nil, err
+	}
+	return &terminal{fd: fd, saved: *saved}, nil
+}
+
+// restore puts the terminal back in the mode it was opened in
+func (t *terminal) restore() error {
+	return unix.IoctlSetTermios(t.fd, ioctlWriteTermios, &t.saved)
+}
+
+// size returns the width and height of the terminal in characters
+func (t *terminal) size() (int, int) {
+	ws, err := unix.IoctlGetWinsize(t.fd, unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+// notifyResize sends to c whenever the terminal is resized
+func notifyResize(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
+
+// openURL opens a URL in the default browser without waiting for it
+func openURL(url string) error {
+	cmd := exec.Command(openCommand, url)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the process once the browser has taken the URL
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -15,21 +15,77 @@
 package cmd
 
 import (
+	"fmt"
+
+	"github.com/apex/log"
+	"github.com/duckpuppy/algolia-hugo/app"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var (
+	syncUpdate   bool
+	atomicUpdate bool
+)
+
 // updateCmd represents the update command
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Upload a new set of search index objects from a JSON file",
 	Run: func(cmd *cobra.Command, args []string) {
-		config.UploadIndex()
+		if syncUpdate && atomicUpdate {
+			log.Fatal("--sync and --atomic cannot be used together")
+		}
+
+		if len(config.Languages) == 0 || sourceLang != "" {
+			c, err := languageConfig()
+			if err != nil {
+				log.WithError(err).Fatal("Invalid languages")
+			}
+			update(c)
+			return
+		}
+
+		configs, err := config.LanguageConfigs()
+		if err != nil {
+			log.WithError(err).Fatal("Invalid languages")
+		}
+		for _, c := range configs {
+			log.WithField("lang", c.Lang).WithField("index", c.AlgoliaIndexName).Info("Updating language")
+			update(c)
+			if c.DryRun {
+				log.WithField("lang", c.Lang).WithField("operations", len(c.DryRunOperations())).Info("Dry run of the language complete")
+			}
+		}
 	},
 }
 
+// update runs the selected kind of update with one configuration
+func update(c *app.Config) {
+	if atomicUpdate {
+		if err := c.AtomicUploadIndex(); err != nil {
+			log.WithError(err).WithField("file", c.UploadFile).Fatal("Failed to reindex atomically")
+		}
+		return
+	}
+
+	if !syncUpdate {
+		c.UploadIndex()
+		return
+	}
+
+	result, err := c.SyncIndex()
+	if err != nil {
+		log.WithError(err).WithField("file", c.UploadFile).Fatal("Failed to sync index")
+	}
+	fmt.Printf("Created: %d\nUpdated: %d\nDeleted: %d\nUnchanged: %d\n",
+		result.Created, result.Updated, result.Deleted, result.Unchanged)
+}
+
 func init() {
 	rootCmd.AddCommand(updateCmd)
-	updateCmd.Flags().StringVarP(&config.UploadFile, "file", "f", "public/index.json", "The file to upload")
+	addSourceFlags(updateCmd)
 	_ = viper.BindPFlag("UploadFile", updateCmd.Flags().Lookup("file"))
+	updateCmd.Flags().BoolVar(&syncUpdate, "sync", false, "Only send the records that were added, changed or removed")
+	updateCmd.Flags().BoolVar(&atomicUpdate, "atomic", false, "Build a temporary index and swap it in place of the live one")
 }
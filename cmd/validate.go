@@ -0,0 +1,90 @@
+// Copyright © 2018 Patrick Aikens
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/duckpuppy/algolia-hugo/app"
+	"github.com/spf13/cobra"
+)
+
+var validateFormat string
+
+// validateErrorStatus is the exit status of validate when it cannot load the
+// records, kept apart from the status 1 that reports violations
+const validateErrorStatus = 2
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the records to upload without sending anything to Algolia",
+	Long: `Check the records to upload without sending anything to Algolia.
+
+Every record must have a unique string objectID, fit within the configured
+record size and carry the configured required attributes. The same checks run
+before every update. The command exits with status 1 when any record fails
+and with status 2 when the records cannot be loaded.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if validateFormat != "text" && validateFormat != "json" {
+			validateFailed(log.Log, fmt.Sprintf("Unknown format %q", validateFormat))
+		}
+
+		c, err := languageConfig()
+		if err != nil {
+			validateFailed(log.WithError(err), "Invalid languages")
+		}
+		violations, err := c.ValidateObjects()
+		if err != nil {
+			validateFailed(log.WithError(err).WithField("file", c.UploadFile), "Failed to load the upload file")
+		}
+
+		switch validateFormat {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if violations == nil {
+				violations = []app.Violation{}
+			}
+			if err = enc.Encode(violations); err != nil {
+				validateFailed(log.WithError(err), "Failed to write violations")
+			}
+		case "text":
+			for _, v := range violations {
+				fmt.Println(v)
+			}
+			fmt.Printf("%d violations\n", len(violations))
+		}
+
+		if len(violations) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// validateFailed logs the error and exits with validateErrorStatus
+func validateFailed(ctx log.Interface, msg string) {
+	ctx.Error(msg)
+	os.Exit(validateErrorStatus)
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	addSourceFlags(validateCmd)
+	validateCmd.Flags().StringVar(&validateFormat, "format", "text", "Output format (text or json)")
+}